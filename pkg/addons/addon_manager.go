@@ -1,20 +1,27 @@
 package addons
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"turtlesilicon/pkg/debug"
 	"turtlesilicon/pkg/paths"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/version"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -28,21 +35,75 @@ type Addon struct {
 	LocalCommit  string
 	RemoteCommit string
 	NeedsUpdate  bool
+	SourceType   string
+	SourceURL    string
+
+	// Pinning state for git addons
+	PinnedRef      string
+	PinnedKind     string
+	PreviousCommit string
+
+	// Commits between LocalCommit and RemoteCommit, filled in by update checks
+	Changelog []CommitInfo
+
+	// Working tree state: modified tracked files and commits ahead/behind the remote
+	LocalChanges int
+	Ahead        int
+	Behind       int
+	Diverged     bool
+
+	// Metadata parsed from the addon's .toc file
+	Title                      string
+	Author                     string
+	Version                    string
+	Interface                  string
+	Dependencies               []string
+	OptionalDeps               []string
+	SavedVariables             []string
+	SavedVariablesPerCharacter []string
+	InterfaceMismatch          bool
+	Linked                     bool
 }
 
 type AddonManager struct {
 	addons           []Addon
+	dependencyIssues []DependencyIssue
 	window           fyne.Window
+	gameVersion      *version.GameVersion
+	gitBackend       gitBackend
 	currentPopup     *widget.PopUp
 	showOnlyGit      bool
 	addonsList       *container.Scroll
 	contentContainer *fyne.Container
 }
 
-func NewAddonManager(window fyne.Window) *AddonManager {
-	return &AddonManager{
-		window: window,
+// NewAddonManager creates an addon manager for the given game version. If gameVersion is nil
+// the currently selected version is loaded from disk.
+func NewAddonManager(window fyne.Window, gameVersion *version.GameVersion) *AddonManager {
+	am := &AddonManager{
+		window:      window,
+		gameVersion: gameVersion,
+		gitBackend:  newGitBackend(),
 	}
+	debug.Printf("Addon manager using %s", am.gitBackend.Name())
+
+	if am.gameVersion == nil {
+		if vm, err := version.LoadVersionManager(); err == nil {
+			if currentVer, err := vm.GetCurrentVersion(); err == nil {
+				am.gameVersion = currentVer
+			}
+		}
+	}
+
+	return am
+}
+
+// expectedInterface returns the ## Interface number addons should declare for the current version
+func (am *AddonManager) expectedInterface() string {
+	if am.gameVersion == nil {
+		return ""
+	}
+	return interfaceVersionFor(am.gameVersion.WoWVersion)
 }
 
 func (am *AddonManager) ScanAddons() error {
@@ -50,15 +111,41 @@ func (am *AddonManager) ScanAddons() error {
 }
 
 func (am *AddonManager) ScanAddonsWithProgress(updateProgress func(string)) error {
-	return am.scanAddonsWithProgress(updateProgress, false)
+	return am.scanAddonsWithProgress(context.Background(), updateProgress, false)
 }
 
-func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), checkUpdates bool) error {
+// gamePath returns the game directory the addon manager operates on
+func (am *AddonManager) gamePath() (string, error) {
+	if am.gameVersion != nil {
+		if am.gameVersion.GamePath == "" {
+			return "", fmt.Errorf("game path not set for %s", am.gameVersion.DisplayName)
+		}
+		return am.gameVersion.GamePath, nil
+	}
+
+	// Without version data fall back to the legacy TurtleSilicon path
 	if paths.TurtlewowPath == "" {
-		return fmt.Errorf("game path not set")
+		return "", fmt.Errorf("game path not set")
 	}
 
-	addonsPath := filepath.Join(paths.TurtlewowPath, "Interface", "Addons")
+	return paths.TurtlewowPath, nil
+}
+
+// addonsPath returns the Interface/AddOns directory of the game, whatever its casing
+func (am *AddonManager) addonsPath() (string, error) {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return "", err
+	}
+
+	return findAddonsDir(gamePath), nil
+}
+
+func (am *AddonManager) scanAddonsWithProgress(ctx context.Context, updateProgress func(string), checkUpdates bool) error {
+	addonsPath, err := am.addonsPath()
+	if err != nil {
+		return err
+	}
 
 	if _, err := os.Stat(addonsPath); os.IsNotExist(err) {
 		return fmt.Errorf("addons directory not found: %s", addonsPath)
@@ -74,9 +161,17 @@ func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), chec
 	}
 
 	am.addons = []Addon{}
+	store := loadAddonStore()
 
 	for i, entry := range entries {
-		if !entry.IsDir() {
+		// Hidden directories are staging folders left by zip installs
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		// Addons linked from another version's folder show up as symlinks
+		linked := entry.Type()&os.ModeSymlink != 0
+		if !entry.IsDir() && !(linked && utils.DirExists(filepath.Join(addonsPath, entry.Name()))) {
 			continue
 		}
 
@@ -85,42 +180,59 @@ func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), chec
 		}
 
 		addonPath := filepath.Join(addonsPath, entry.Name())
+
+		// Only folders with a .toc file are loaded by the client
+		tocPath := findTocFile(addonPath)
+		if tocPath == "" {
+			debug.Printf("Skipping %s: no .toc file found", entry.Name())
+			continue
+		}
+
 		addon := Addon{
-			Name: entry.Name(),
-			Path: addonPath,
+			Name:   entry.Name(),
+			Path:   addonPath,
+			Linked: linked,
 		}
+		am.applyTocInfo(&addon, tocPath)
 
 		gitPath := filepath.Join(addonPath, ".git")
 		if _, err := os.Stat(gitPath); err == nil {
 			addon.HasGitRepo = true
 			addon.GitRemoteURL = am.getGitRemoteURL(addonPath)
 			addon.LocalCommit = am.getLocalCommit(addonPath)
-
-			if checkUpdates {
-				if updateProgress != nil {
-					updateProgress(fmt.Sprintf("Checking updates for %s...", entry.Name()))
-				}
-
-				addon.RemoteCommit = am.getRemoteCommit(addonPath)
-				addon.NeedsUpdate = addon.LocalCommit != addon.RemoteCommit && addon.RemoteCommit != ""
-			} else {
-				addon.RemoteCommit = ""
-				addon.NeedsUpdate = false
+			addon.LocalChanges = am.countLocalChanges(ctx, addonPath)
+			if source := store.Sources[filepath.Clean(addonPath)]; source != nil && source.Type == SourceTypeGit {
+				addon.PinnedRef = source.PinnedRef
+				addon.PinnedKind = source.PinnedKind
+				addon.PreviousCommit = source.PreviousCommit
 			}
+			addon.SourceType = SourceTypeGit
+			addon.SourceURL = addon.GitRemoteURL
+		} else if source := store.Sources[filepath.Clean(addonPath)]; source != nil {
+			addon.SourceType = source.Type
+			addon.SourceURL = source.URL
 		}
 
 		if info, err := entry.Info(); err == nil {
 			addon.LastUpdated = info.ModTime()
 		}
 
-		addon.Description = am.getAddonDescription(addonPath)
 		am.addons = append(am.addons, addon)
 	}
 
+	if checkUpdates {
+		am.checkRemoteCommits(ctx, updateProgress)
+	}
+
 	if updateProgress != nil {
 		updateProgress("Finalizing...")
 	}
 
+	am.dependencyIssues = am.CheckDependencies()
+	for _, issue := range am.dependencyIssues {
+		debug.Printf("Dependency issue: %s", issue)
+	}
+
 	if checkUpdates {
 		debug.Printf("Found %d addons (%d with git repos, %d need updates)", len(am.addons), am.countGitAddons(), am.countUpdatableAddons())
 	} else {
@@ -130,34 +242,41 @@ func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), chec
 }
 
 func (am *AddonManager) getGitRemoteURL(addonPath string) string {
-	gitConfigPath := filepath.Join(addonPath, ".git", "config")
-	if content, err := os.ReadFile(gitConfigPath); err == nil {
-		lines := strings.Split(string(content), "\n")
-		for i, line := range lines {
-			if strings.Contains(line, "[remote \"origin\"]") && i+1 < len(lines) {
-				urlLine := strings.TrimSpace(lines[i+1])
-				if strings.HasPrefix(urlLine, "url = ") {
-					return strings.TrimPrefix(urlLine, "url = ")
-				}
-			}
-		}
+	return am.git().RemoteURL(addonPath)
+}
+
+// applyTocInfo fills in the addon's metadata from its .toc file and flags Interface mismatches
+func (am *AddonManager) applyTocInfo(addon *Addon, tocPath string) {
+	addon.Description = "No description available"
+
+	toc, err := parseTocFile(tocPath)
+	if err != nil {
+		debug.Printf("Failed to read %s: %v", tocPath, err)
+		return
+	}
+
+	addon.Title = toc.Title
+	addon.Author = toc.Author
+	addon.Version = toc.Version
+	addon.Interface = toc.Interface
+	addon.Dependencies = toc.Dependencies
+	addon.OptionalDeps = toc.OptionalDeps
+	addon.SavedVariables = toc.SavedVariables
+	addon.SavedVariablesPerCharacter = toc.SavedVariablesPerCharacter
+	if toc.Notes != "" {
+		addon.Description = toc.Notes
 	}
-	return ""
+
+	expected := am.expectedInterface()
+	addon.InterfaceMismatch = expected != "" && toc.Interface != "" && toc.Interface != expected
 }
 
-func (am *AddonManager) getAddonDescription(addonPath string) string {
-	tocFiles, _ := filepath.Glob(filepath.Join(addonPath, "*.toc"))
-	if len(tocFiles) > 0 {
-		if content, err := os.ReadFile(tocFiles[0]); err == nil {
-			lines := strings.Split(string(content), "\n")
-			for _, line := range lines {
-				if strings.HasPrefix(line, "## Notes:") {
-					return strings.TrimSpace(strings.TrimPrefix(line, "## Notes:"))
-				}
-			}
-		}
+// DisplayName returns the addon's .toc title, falling back to its folder name
+func (a *Addon) DisplayName() string {
+	if a.Title != "" {
+		return a.Title
 	}
-	return "No description available"
+	return a.Name
 }
 
 func (am *AddonManager) countGitAddons() int {
@@ -170,6 +289,16 @@ func (am *AddonManager) countGitAddons() int {
 	return count
 }
 
+func (am *AddonManager) countIncompatibleAddons() int {
+	count := 0
+	for _, addon := range am.addons {
+		if addon.InterfaceMismatch {
+			count++
+		}
+	}
+	return count
+}
+
 func (am *AddonManager) countUpdatableAddons() int {
 	count := 0
 	for _, addon := range am.addons {
@@ -181,55 +310,96 @@ func (am *AddonManager) countUpdatableAddons() int {
 }
 
 func (am *AddonManager) getLocalCommit(addonPath string) string {
-	cmd := exec.Command("git", "rev-parse", "HEAD")
-	cmd.Dir = addonPath
-
-	output, err := cmd.Output()
+	commit, err := am.git().HeadCommit(addonPath)
 	if err != nil {
 		debug.Printf("Failed to get local commit for %s: %v", addonPath, err)
 		return ""
 	}
 
-	return strings.TrimSpace(string(output))
+	return commit
 }
 
-func (am *AddonManager) getRemoteCommit(addonPath string) string {
-	fetchCmd := exec.Command("git", "fetch", "origin")
-	fetchCmd.Dir = addonPath
-	fetchCmd.Run()
-
-	// Get the remote commit hash
-	cmd := exec.Command("git", "rev-parse", "origin/HEAD")
-	cmd.Dir = addonPath
+// checkRemoteCommits fetches every git addon in parallel and marks those with new commits
+func (am *AddonManager) checkRemoteCommits(ctx context.Context, updateProgress func(string)) {
+	var indexes []int
+	var names []string
+	for i := range am.addons {
+		if am.addons[i].HasGitRepo {
+			indexes = append(indexes, i)
+			names = append(names, am.addons[i].Name)
+		}
+	}
 
-	output, err := cmd.Output()
-	if err != nil {
-		cmd = exec.Command("git", "rev-parse", "origin/main")
-		cmd.Dir = addonPath
-		output, err = cmd.Output()
-		if err != nil {
-			cmd = exec.Command("git", "rev-parse", "origin/master")
-			cmd.Dir = addonPath
-			output, err = cmd.Output()
+	var mu sync.Mutex
+	checked := 0
+	concurrency, timeout := addonPoolSettings()
+	runTasks(ctx, names, concurrency, timeout, func(taskCtx context.Context, i int) (string, error) {
+		addon := &am.addons[indexes[i]]
+		addon.RemoteCommit = am.getRemoteCommit(taskCtx, addon)
+		addon.NeedsUpdate = addon.LocalCommit != addon.RemoteCommit && addon.RemoteCommit != ""
+		am.checkDivergence(taskCtx, addon)
+		if addon.NeedsUpdate && addon.Ahead > 0 && addon.Behind == 0 {
+			// Only local commits, there is nothing to pull
+			addon.NeedsUpdate = false
+		}
+		if addon.NeedsUpdate {
+			changelog, err := am.Changelog(taskCtx, addon)
 			if err != nil {
-				debug.Printf("Failed to get remote commit for %s: %v", addonPath, err)
-				return ""
+				debug.Printf("Failed to read changelog for %s: %v", addon.Name, err)
 			}
+			addon.Changelog = changelog
+		}
+		return "", taskCtx.Err()
+	}, nil, func(i int, result TaskResult) {
+		if updateProgress == nil {
+			return
 		}
+		mu.Lock()
+		checked++
+		message := fmt.Sprintf("Checked %s (%d/%d)", result.Name, checked, len(names))
+		mu.Unlock()
+		updateProgress(message)
+	})
+}
+
+// getRemoteCommit fetches origin and returns the commit the addon would update to: the
+// pinned ref if there is one, otherwise the upstream of the checked out branch
+func (am *AddonManager) getRemoteCommit(ctx context.Context, addon *Addon) string {
+	commit, err := am.git().RemoteCommit(ctx, addon.Path, addon.PinnedKind, addon.PinnedRef)
+	if err != nil {
+		debug.Printf("Failed to get remote commit for %s: %v", addon.Path, err)
+		return ""
 	}
 
-	return strings.TrimSpace(string(output))
+	return commit
 }
 
-func (am *AddonManager) UpdateAddon(addon *Addon) error {
-	if !addon.HasGitRepo {
-		return fmt.Errorf("addon %s does not have a git repository", addon.Name)
+// UpdateAddon updates an addon from its source. Strategy decides what happens to git addons
+// with local changes or a diverged history; the returned string describes what was done.
+func (am *AddonManager) UpdateAddon(ctx context.Context, addon *Addon, strategy string) (string, error) {
+	if !addon.HasGitRepo && addon.SourceType != SourceTypeZip {
+		return "", fmt.Errorf("addon %s does not have a git repository or recorded source", addon.Name)
 	}
 
+	// The background updater and the manager window have their own AddonManager, so updates
+	// of the same folder are serialized here
+	unlock := lockAddonPath(addon.Path)
+	defer unlock()
+
 	debug.Printf("Updating addon: %s", addon.Name)
 
-	if err := am.runGitPull(addon.Path); err != nil {
-		return fmt.Errorf("failed to update addon %s: %v", addon.Name, err)
+	var detail string
+	if addon.SourceType == SourceTypeZip {
+		if err := am.updateZipAddon(ctx, addon); err != nil {
+			return "", fmt.Errorf("failed to update addon %s: %v", addon.Name, err)
+		}
+	} else {
+		var err error
+		if detail, err = am.updateGitAddon(ctx, addon, strategy); errors.Is(err, ErrSkipped) {
+			return "", err
+		} else if err != nil {
+			return "", fmt.Errorf("failed to update addon %s: %w", addon.Name, err)
+		}
 	}
 
 	// Update the directory's modification time to reflect the update
@@ -240,39 +410,50 @@ func (am *AddonManager) UpdateAddon(addon *Addon) error {
 
 	addon.LastUpdated = now
 	debug.Printf("Successfully updated addon: %s", addon.Name)
-	return nil
+	return detail, nil
 }
 
 func (am *AddonManager) DeleteAddon(addon *Addon) error {
 	debug.Printf("Deleting addon: %s", addon.Name)
 
-	if err := os.RemoveAll(addon.Path); err != nil {
-		return fmt.Errorf("failed to delete addon %s: %v", addon.Name, err)
+	if dependents := am.Dependents(addon.Name); len(dependents) > 0 {
+		debug.Printf("Warning: %s is required by %s", addon.Name, strings.Join(dependents, ", "))
+	}
+
+	// Folders unpacked from the same archive only work together, so they go together
+	paths := append([]string{addon.Path}, packSiblings(addon)...)
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to delete addon %s: %v", filepath.Base(path), err)
+		}
+		if err := removeAddonSource(path); err != nil {
+			debug.Printf("Warning: failed to forget source for %s: %v", filepath.Base(path), err)
+		}
 	}
 
-	for i, a := range am.addons {
-		if a.Name == addon.Name {
-			am.addons = append(am.addons[:i], am.addons[i+1:]...)
-			break
+	deleted := make(map[string]bool)
+	for _, path := range paths {
+		deleted[filepath.Clean(path)] = true
+	}
+	remaining := am.addons[:0]
+	for _, a := range am.addons {
+		if !deleted[filepath.Clean(a.Path)] && a.Name != addon.Name {
+			remaining = append(remaining, a)
 		}
 	}
+	am.addons = remaining
 
 	debug.Printf("Successfully deleted addon: %s", addon.Name)
 	return nil
 }
 
-func (am *AddonManager) runGitPull(addonPath string) error {
-	cmd := exec.Command("git", "pull")
-	cmd.Dir = addonPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		debug.Printf("Git pull failed: %s", string(output))
-		return fmt.Errorf("git pull failed: %v", err)
-	}
+func (am *AddonManager) runGitPull(ctx context.Context, addonPath string) error {
+	return am.git().Pull(ctx, addonPath)
+}
 
-	debug.Printf("Git pull output: %s", string(output))
-	return nil
+// getCurrentBranch returns the checked out branch, or an empty string for a detached HEAD
+func (am *AddonManager) getCurrentBranch(addonPath string) string {
+	return am.git().CurrentBranch(addonPath)
 }
 
 func (am *AddonManager) ShowAddonManager() {
@@ -325,9 +506,19 @@ func (am *AddonManager) ShowAddonManager() {
 
 func (am *AddonManager) createAddonManagerPopup() {
 	titleText := widget.NewLabel("Addon Manager")
+	if am.gameVersion != nil {
+		titleText.SetText("Addon Manager - " + am.gameVersion.DisplayName)
+	}
 	titleText.TextStyle = fyne.TextStyle{Bold: true}
 
-	summaryText := widget.NewLabel(fmt.Sprintf("Found %d addons (%d git repos, %d need updates)", len(am.addons), am.countGitAddons(), am.countUpdatableAddons()))
+	summary := fmt.Sprintf("Found %d addons (%d git repos, %d need updates)", len(am.addons), am.countGitAddons(), am.countUpdatableAddons())
+	if incompatible := am.countIncompatibleAddons(); incompatible > 0 {
+		summary += fmt.Sprintf(", %d for another client", incompatible)
+	}
+	if len(am.dependencyIssues) > 0 {
+		summary += fmt.Sprintf(", %d dependency issues", len(am.dependencyIssues))
+	}
+	summaryText := widget.NewLabel(summary)
 	summaryText.TextStyle = fyne.TextStyle{Italic: true}
 
 	refreshButton := widget.NewButton("Refresh", func() {})
@@ -337,8 +528,14 @@ func (am *AddonManager) createAddonManagerPopup() {
 		am.updateAllAddons()
 	})
 	updateAllButton.Importance = widget.HighImportance
+	reviewButton := widget.NewButton("Review Updates", func() {
+		am.showReviewUpdatesPopup()
+	})
+	reviewButton.Importance = widget.MediumImportance
+
 	if am.countUpdatableAddons() == 0 {
 		updateAllButton.Disable()
+		reviewButton.Disable()
 	}
 
 	addButton := widget.NewButton("Add", func() {
@@ -346,21 +543,75 @@ func (am *AddonManager) createAddonManagerPopup() {
 	})
 	addButton.Importance = widget.HighImportance
 
+	// Less frequent tools live in a menu so the header stays narrow
+	var moreButton *widget.Button
+	moreButton = widget.NewButtonWithIcon("More", theme.MoreHorizontalIcon(), func() {
+		menu := fyne.NewMenu("",
+			fyne.NewMenuItem("Characters...", am.showCharactersPopup),
+			fyne.NewMenuItem("SavedVariables...", am.showSavedVariablesPopup),
+			fyne.NewMenuItem("WTF Backup...", am.showWTFBackupPopup),
+			fyne.NewMenuItem("Lockfile...", am.showLockfilePopup),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Update Settings...", am.showUpdateSettingsPopup),
+		)
+		position := fyne.CurrentApp().Driver().AbsolutePositionForObject(moreButton)
+		widget.ShowPopUpMenuAtPosition(menu, am.window.Canvas(), position.Add(fyne.NewPos(0, moreButton.Size().Height)))
+	})
+	moreButton.Importance = widget.MediumImportance
+
 	onlyGitCheckbox := widget.NewCheck("Only GIT", func(checked bool) {
 		am.showOnlyGit = checked
 		am.refreshAddonList()
 	})
 	onlyGitCheckbox.SetChecked(am.showOnlyGit)
 
-	leftSide := container.NewHBox(summaryText, onlyGitCheckbox)
-	rightSide := container.NewHBox(addButton, refreshButton, updateAllButton)
+	concurrency, _ := addonPoolSettings()
+	concurrencySelect := widget.NewSelect([]string{"1", "2", "4", "8", "16"}, nil)
+	concurrencySelect.SetSelected(strconv.Itoa(concurrency))
+	concurrencySelect.OnChanged = func(selected string) {
+		value, err := strconv.Atoi(selected)
+		if err != nil {
+			return
+		}
+		prefs, err := utils.LoadPrefs()
+		if err != nil {
+			return
+		}
+		prefs.AddonConcurrency = value
+		if err := utils.SavePrefs(prefs); err != nil {
+			debug.Printf("Failed to save addon concurrency: %v", err)
+		}
+	}
+
+	systemGitCheckbox := widget.NewCheck("Use system git", nil)
+	if prefs, err := utils.LoadPrefs(); err == nil {
+		systemGitCheckbox.SetChecked(prefs.PreferSystemGit)
+	}
+	if !hasSystemGit() {
+		// Without the Command Line Tools only the built-in implementation works
+		systemGitCheckbox.Disable()
+	}
+	systemGitCheckbox.OnChanged = func(checked bool) {
+		prefs, err := utils.LoadPrefs()
+		if err != nil {
+			return
+		}
+		prefs.PreferSystemGit = checked
+		if err := utils.SavePrefs(prefs); err != nil {
+			debug.Printf("Failed to save git preference: %v", err)
+		}
+		am.gitBackend = newGitBackend()
+		debug.Printf("Addon manager now using %s", am.gitBackend.Name())
+	}
+
+	leftSide := container.NewHBox(summaryText)
+	rightSide := container.NewHBox(addButton, refreshButton, reviewButton, updateAllButton, moreButton)
+	optionsRow := container.NewHBox(onlyGitCheckbox, systemGitCheckbox, widget.NewLabel("Parallel:"), concurrencySelect)
 
-	headerContainer := container.NewBorder(
-		nil,
+	headerContainer := container.NewVBox(
+		container.NewBorder(nil, nil, leftSide, rightSide, nil),
+		optionsRow,
 		widget.NewSeparator(),
-		leftSide,
-		rightSide,
-		nil,
 	)
 
 	am.addonsList = am.createAddonsList()
@@ -458,7 +709,7 @@ func (am *AddonManager) createAddonsList() *container.Scroll {
 		if am.showOnlyGit {
 			emptyMessage = "No GIT addons found"
 		} else {
-			emptyMessage = "No addons found in Interface/Addons directory"
+			emptyMessage = "No addons found in Interface/AddOns directory"
 		}
 		emptyLabel := widget.NewLabel(emptyMessage)
 		emptyLabel.Alignment = fyne.TextAlignCenter
@@ -507,25 +758,37 @@ func (am *AddonManager) refreshWithUpdateCheck() {
 	progressBar := widget.NewProgressBarInfinite()
 	progressBar.Start()
 
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Cancelling skips the remaining update checks but still shows the scanned addons
+	cancelButton := widget.NewButton("Cancel", nil)
+	cancelButton.OnTapped = func() {
+		cancelButton.Disable()
+		cancel()
+	}
+
 	loadingContent := container.NewVBox(
 		widget.NewLabel("Refreshing Addons"),
 		widget.NewSeparator(),
 		loadingText,
 		progressBar,
+		container.NewCenter(cancelButton),
 	)
 
 	loadingPopup := widget.NewModalPopUp(container.NewPadded(loadingContent), am.window.Canvas())
-	loadingPopup.Resize(fyne.NewSize(300, 150))
+	loadingPopup.Resize(fyne.NewSize(300, 180))
 	loadingPopup.Show()
 
 	go func() {
+		defer cancel()
+
 		updateProgress := func(message string) {
 			fyne.Do(func() {
 				loadingText.SetText(message)
 			})
 		}
 
-		if err := am.scanAddonsWithProgress(updateProgress, true); err != nil {
+		if err := am.scanAddonsWithProgress(ctx, updateProgress, true); err != nil {
 			fyne.Do(func() {
 				progressBar.Stop()
 				loadingPopup.Hide()
@@ -533,6 +796,10 @@ func (am *AddonManager) refreshWithUpdateCheck() {
 			})
 			return
 		}
+		// A cancelled check only covers some addons, so don't cache it
+		if ctx.Err() == nil {
+			am.recordUpdateCheck()
+		}
 
 		fyne.Do(func() {
 			progressBar.Stop()
@@ -543,13 +810,20 @@ func (am *AddonManager) refreshWithUpdateCheck() {
 }
 
 func (am *AddonManager) createAddonCard(addon *Addon) *fyne.Container {
-	nameText := fmt.Sprintf("**%s**", addon.Name)
+	nameText := fmt.Sprintf("**%s**", addon.DisplayName())
+	if addon.Version != "" {
+		nameText += fmt.Sprintf(" %s", addon.Version)
+	}
 
 	nameLabel := widget.NewRichTextFromMarkdown(nameText)
 	nameLabel.Wrapping = fyne.TextWrapOff
 
 	lastUpdatedLabel := widget.NewLabel(fmt.Sprintf("Last modified: %s", addon.LastUpdated.Format("2006-01-02 15:04")))
 	lastUpdatedLabel.TextStyle = fyne.TextStyle{Italic: true}
+	if len(addon.Changelog) > 0 {
+		lastUpdatedLabel.SetText(fmt.Sprintf("%d new commits, latest: %s", len(addon.Changelog), addon.Changelog[0].Subject))
+		lastUpdatedLabel.Truncation = fyne.TextTruncateEllipsis
+	}
 
 	nameContainer := container.NewHBox(nameLabel)
 
@@ -572,25 +846,84 @@ func (am *AddonManager) createAddonCard(addon *Addon) *fyne.Container {
 		} else {
 			gitText = "GIT"
 		}
+		if addon.PinnedKind == PinCommit {
+			gitText += " @" + shortCommit(addon.PinnedRef)
+		} else if addon.PinnedRef != "" {
+			gitText += " @" + addon.PinnedRef
+		}
 
 		gitButton = widget.NewButton(gitText, func() {})
 		gitButton.Importance = widget.LowImportance
 		gitButton.Disable()
+	} else if addon.SourceType == SourceTypeZip {
+		gitButton = widget.NewButton("ZIP", func() {})
+		gitButton.Importance = widget.LowImportance
+		gitButton.Disable()
+	}
+	if addon.Linked && gitButton != nil {
+		gitButton.SetText(gitButton.Text + " LINK")
+	} else if addon.Linked {
+		gitButton = widget.NewButton("LINK", func() {})
+		gitButton.Importance = widget.LowImportance
+		gitButton.Disable()
+	}
+
+	var interfaceButton *widget.Button
+	if addon.InterfaceMismatch {
+		interfaceButton = widget.NewButton(fmt.Sprintf("Interface %s", addon.Interface), func() {
+			am.showAddonInfoPopup(addon)
+		})
+		interfaceButton.Importance = widget.WarningImportance
+	}
+
+	var stateButton *widget.Button
+	if addon.Diverged {
+		stateButton = widget.NewButton("Diverged", func() {
+			am.updateSingleAddon(addon)
+		})
+		stateButton.Importance = widget.DangerImportance
+	} else if addon.LocalChanges > 0 {
+		stateButton = widget.NewButton(fmt.Sprintf("Modified (%d)", addon.LocalChanges), func() {
+			am.updateSingleAddon(addon)
+		})
+		stateButton.Importance = widget.WarningImportance
+	}
+
+	var dependencyButton *widget.Button
+	if issues := issuesForAddon(am.dependencyIssues, addon.Name); len(issues) > 0 {
+		dependencyButton = widget.NewButton("Deps!", func() {
+			am.showDependencyIssuesPopup(addon.DisplayName(), issues)
+		})
+		dependencyButton.Importance = widget.WarningImportance
+	}
+
+	infoButton := widget.NewButton("Info", func() {
+		am.showAddonInfoPopup(addon)
+	})
+	infoButton.Importance = widget.LowImportance
+
+	var changesButton *widget.Button
+	if len(addon.Changelog) > 0 {
+		changesButton = widget.NewButton("Changes", func() {
+			am.showChangelogPopup(addon)
+		})
+		changesButton.Importance = widget.LowImportance
 	}
 
-	var infoButton *widget.Button
-	if addon.Description != "" && addon.Description != "No description available" {
-		infoButton = widget.NewButton("Info", func() {
-			am.showDescriptionPopup(addon.Name, addon.Description)
+	var versionButton *widget.Button
+	if addon.HasGitRepo {
+		versionButton = widget.NewButton("Version", func() {
+			am.showPinPopup(addon)
 		})
-		infoButton.Importance = widget.LowImportance
+		versionButton.Importance = widget.LowImportance
 	}
 
 	updateButton := widget.NewButton("Update", func() {
 		am.updateSingleAddon(addon)
 	})
 	updateButton.Importance = widget.MediumImportance
-	if !addon.HasGitRepo || !addon.NeedsUpdate {
+	// Zip addons can't be checked without downloading, so they can always be updated
+	if !(addon.HasGitRepo && addon.NeedsUpdate) && addon.SourceType != SourceTypeZip {
 		updateButton.Disable()
 	}
 
@@ -605,8 +938,21 @@ func (am *AddonManager) createAddonCard(addon *Addon) *fyne.Container {
 	if gitButton != nil {
 		buttons = append(buttons, gitButton)
 	}
-	if infoButton != nil {
-		buttons = append(buttons, infoButton)
+	if interfaceButton != nil {
+		buttons = append(buttons, interfaceButton)
+	}
+	if stateButton != nil {
+		buttons = append(buttons, stateButton)
+	}
+	if dependencyButton != nil {
+		buttons = append(buttons, dependencyButton)
+	}
+	if changesButton != nil {
+		buttons = append(buttons, changesButton)
+	}
+	buttons = append(buttons, infoButton)
+	if versionButton != nil {
+		buttons = append(buttons, versionButton)
 	}
 	buttons = append(buttons, updateButton, deleteButton)
 
@@ -628,18 +974,41 @@ func (am *AddonManager) createAddonCard(addon *Addon) *fyne.Container {
 }
 
 func (am *AddonManager) updateSingleAddon(addon *Addon) {
+	if addon.HasLocalState() {
+		am.chooseUpdateStrategy([]Addon{*addon}, func(strategy string) {
+			am.updateSingleAddonWithStrategy(addon, strategy)
+		})
+		return
+	}
+	am.updateSingleAddonWithStrategy(addon, StrategyPull)
+}
+
+func (am *AddonManager) updateSingleAddonWithStrategy(addon *Addon, strategy string) {
 	progressDialog := dialog.NewProgressInfinite("Updating addon", fmt.Sprintf("Updating %s...", addon.Name), am.window)
 	progressDialog.Show()
 
 	go func() {
-		defer progressDialog.Hide()
+		am.snapshotBeforeUpdate()
+		detail, err := am.UpdateAddon(context.Background(), addon, strategy)
+		am.forgetUpdates([]TaskResult{{Name: addon.Name, Err: err}})
 
-		if err := am.UpdateAddon(addon); err != nil {
-			dialog.ShowError(err, am.window)
-		} else {
-			dialog.ShowInformation("Success", fmt.Sprintf("Successfully updated %s", addon.Name), am.window)
+		fyne.Do(func() {
+			progressDialog.Hide()
+			if errors.Is(err, ErrSkipped) {
+				return
+			}
+			if err != nil {
+				dialog.ShowError(err, am.window)
+				return
+			}
+
+			message := fmt.Sprintf("Successfully updated %s", addon.Name)
+			if detail != "" {
+				message += fmt.Sprintf(": %s", detail)
+			}
+			dialog.ShowInformation("Success", message, am.window)
 			am.ShowAddonManager()
-		}
+		})
 	}()
 }
 
@@ -656,33 +1025,169 @@ func (am *AddonManager) updateAllAddons() {
 		return
 	}
 
-	progressDialog := dialog.NewProgressInfinite("Updating addons", fmt.Sprintf("Updating %d addons...", len(updatableAddons)), am.window)
-	progressDialog.Show()
+	am.updateAddons(updatableAddons)
+}
+
+// updateAddons asks how to handle addons with local changes, if any, and then updates the
+// given addons through the worker pool
+func (am *AddonManager) updateAddons(updatableAddons []Addon) {
+	var withLocalState []Addon
+	for _, addon := range updatableAddons {
+		if addon.HasLocalState() {
+			withLocalState = append(withLocalState, addon)
+		}
+	}
+
+	if len(withLocalState) == 0 {
+		am.runUpdates(updatableAddons, StrategyPull)
+		return
+	}
+	am.chooseUpdateStrategy(withLocalState, func(strategy string) {
+		am.runUpdates(updatableAddons, strategy)
+	})
+}
+
+// runUpdates updates the given addons through the worker pool and shows the results
+func (am *AddonManager) runUpdates(updatableAddons []Addon, strategy string) {
+	names := make([]string, len(updatableAddons))
+	for i, addon := range updatableAddons {
+		names[i] = addon.Name
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := am.newTaskProgress(fmt.Sprintf("Updating %d addons", len(updatableAddons)), names, cancel)
 
 	go func() {
-		defer progressDialog.Hide()
+		defer cancel()
 
-		updated := 0
-		failed := 0
+		am.snapshotBeforeUpdate()
+		concurrency, timeout := addonPoolSettings()
+		results := runTasks(ctx, names, concurrency, timeout, func(taskCtx context.Context, i int) (string, error) {
+			return am.UpdateAddon(taskCtx, &updatableAddons[i], strategy)
+		}, progress.started, progress.finished)
+		am.forgetUpdates(results)
 
-		for i := range updatableAddons {
-			if err := am.UpdateAddon(&updatableAddons[i]); err != nil {
-				debug.Printf("Failed to update %s: %v", updatableAddons[i].Name, err)
-				failed++
-			} else {
-				updated++
-			}
+		fyne.Do(func() {
+			progress.hide()
+			am.showTaskResults("Update Results", results)
+			am.refreshAddonManager()
+		})
+	}()
+}
+
+// chooseUpdateStrategy asks how addons with local changes or a diverged history should be
+// updated and calls onChosen with the selected strategy
+func (am *AddonManager) chooseUpdateStrategy(addons []Addon, onChosen func(strategy string)) {
+	var lines []string
+	for _, addon := range addons {
+		lines = append(lines, fmt.Sprintf("• %s: %s", addon.DisplayName(), addon.describeLocalState()))
+	}
+	stateLabel := widget.NewLabel(strings.Join(lines, "\n"))
+	stateLabel.Wrapping = fyne.TextWrapWord
+
+	const (
+		stashOption = "Stash local changes, update, then re-apply them"
+		resetOption = "Reset to the remote version (discards local changes and commits)"
+		skipOption  = "Skip these addons"
+	)
+	strategyRadio := widget.NewRadioGroup([]string{stashOption, resetOption, skipOption}, nil)
+	strategyRadio.SetSelected(stashOption)
+
+	content := container.NewBorder(
+		widget.NewLabel("These addons have local modifications:"),
+		strategyRadio,
+		nil,
+		nil,
+		container.NewScroll(stateLabel),
+	)
+
+	strategyDialog := dialog.NewCustomConfirm("Local Changes", "Continue", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		switch strategyRadio.Selected {
+		case resetOption:
+			onChosen(StrategyReset)
+		case skipOption:
+			onChosen(StrategySkip)
+		default:
+			onChosen(StrategyStash)
 		}
+	}, am.window)
+	windowSize := am.window.Content().Size()
+	strategyDialog.Resize(fyne.NewSize(windowSize.Width*2/3, windowSize.Height/2))
+	strategyDialog.Show()
+}
 
-		message := fmt.Sprintf("Update complete!\nUpdated: %d\nFailed: %d", updated, failed)
-		if failed > 0 {
-			dialog.ShowInformation("Update Results", message, am.window)
-		} else {
-			dialog.ShowInformation("Success", message, am.window)
+// showChangelogPopup lists the commits an update would bring in for one addon
+func (am *AddonManager) showChangelogPopup(addon *Addon) {
+	changelogLabel := widget.NewLabel(formatChangelog(addon.Changelog))
+	changelogLabel.Wrapping = fyne.TextWrapWord
+
+	changelogDialog := dialog.NewCustom(fmt.Sprintf("Changes: %s %s → %s", addon.DisplayName(), shortCommit(addon.LocalCommit), shortCommit(addon.RemoteCommit)), "Close", container.NewScroll(changelogLabel), am.window)
+	windowSize := am.window.Content().Size()
+	changelogDialog.Resize(fyne.NewSize(windowSize.Width*3/4, windowSize.Height*2/3))
+	changelogDialog.Show()
+}
+
+// showReviewUpdatesPopup shows the pending changelog of every updatable addon and updates
+// the ones the user keeps selected
+func (am *AddonManager) showReviewUpdatesPopup() {
+	var updatableAddons []Addon
+	for _, addon := range am.addons {
+		if addon.HasGitRepo && addon.NeedsUpdate {
+			updatableAddons = append(updatableAddons, addon)
 		}
+	}
 
-		am.refreshAddonManager()
-	}()
+	list := container.NewVBox()
+	checks := make([]*widget.Check, len(updatableAddons))
+	for i, addon := range updatableAddons {
+		checks[i] = widget.NewCheck(fmt.Sprintf("%s (%d commits)", addon.DisplayName(), len(addon.Changelog)), nil)
+		checks[i].SetChecked(true)
+
+		changelogLabel := widget.NewLabel(formatChangelog(addon.Changelog))
+		changelogLabel.Wrapping = fyne.TextWrapWord
+
+		list.Add(checks[i])
+		list.Add(changelogLabel)
+		if i < len(updatableAddons)-1 {
+			list.Add(widget.NewSeparator())
+		}
+	}
+
+	reviewDialog := dialog.NewCustomConfirm("Review Updates", "Update Selected", "Cancel", container.NewScroll(list), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		var selected []Addon
+		for i, check := range checks {
+			if check.Checked {
+				selected = append(selected, updatableAddons[i])
+			}
+		}
+		if len(selected) > 0 {
+			am.updateAddons(selected)
+		}
+	}, am.window)
+
+	windowSize := am.window.Content().Size()
+	reviewDialog.Resize(fyne.NewSize(windowSize.Width*4/5, windowSize.Height*4/5))
+	reviewDialog.Show()
+}
+
+// formatChangelog renders commits one per line for display
+func formatChangelog(commits []CommitInfo) string {
+	if len(commits) == 0 {
+		return "No commit details available"
+	}
+
+	lines := make([]string, len(commits))
+	for i, commit := range commits {
+		lines[i] = commit.String()
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (am *AddonManager) refreshAddonManager() {
@@ -721,22 +1226,59 @@ func (am *AddonManager) refreshAddonManager() {
 	}()
 }
 
-func (am *AddonManager) showDescriptionPopup(addonName, description string) {
-	titleText := widget.NewRichTextFromMarkdown(fmt.Sprintf("# %s", addonName))
+func (am *AddonManager) showAddonInfoPopup(addon *Addon) {
+	titleText := widget.NewRichTextFromMarkdown(fmt.Sprintf("# %s", addon.DisplayName()))
 	titleText.Wrapping = fyne.TextWrapOff
 
-	descriptionLabel := widget.NewLabel(description)
+	descriptionLabel := widget.NewLabel(addon.Description)
 	descriptionLabel.Wrapping = fyne.TextWrapWord
 
+	savedVariables := append(append([]string{}, addon.SavedVariables...), addon.SavedVariablesPerCharacter...)
+
+	detailsForm := widget.NewForm(
+		widget.NewFormItem("Folder", widget.NewLabel(addon.Name)),
+		widget.NewFormItem("Author", widget.NewLabel(valueOrDash(addon.Author))),
+		widget.NewFormItem("Version", widget.NewLabel(valueOrDash(addon.Version))),
+		widget.NewFormItem("Interface", widget.NewLabel(valueOrDash(addon.Interface))),
+		widget.NewFormItem("Dependencies", widget.NewLabel(valueOrDash(strings.Join(addon.Dependencies, ", ")))),
+		widget.NewFormItem("Optional", widget.NewLabel(valueOrDash(strings.Join(addon.OptionalDeps, ", ")))),
+		widget.NewFormItem("SavedVariables", widget.NewLabel(valueOrDash(strings.Join(savedVariables, ", ")))),
+	)
+
 	contentContainer := container.NewVBox(
 		container.NewCenter(titleText),
 		widget.NewSeparator(),
 		descriptionLabel,
+		widget.NewSeparator(),
+		detailsForm,
 	)
 
+	if addon.InterfaceMismatch {
+		warningLabel := widget.NewLabel(fmt.Sprintf("⚠️ This addon is built for Interface %s, but %s expects %s. It may not load or work correctly.", addon.Interface, am.gameVersion.DisplayName, am.expectedInterface()))
+		warningLabel.Wrapping = fyne.TextWrapWord
+		contentContainer.Add(widget.NewSeparator())
+		contentContainer.Add(warningLabel)
+	}
+
+	if addon.Linked {
+		if target, err := filepath.EvalSymlinks(addon.Path); err == nil {
+			linkLabel := widget.NewLabel("Linked from " + target)
+			linkLabel.Wrapping = fyne.TextWrapWord
+			contentContainer.Add(linkLabel)
+		}
+	}
+
+	if otherVersions := am.otherGameVersions(); len(otherVersions) > 0 {
+		copyButton := widget.NewButton("Copy to Another Version...", func() {
+			am.showCopyToVersionPopup(addon, otherVersions)
+		})
+		contentContainer.Add(widget.NewSeparator())
+		contentContainer.Add(container.NewCenter(copyButton))
+	}
+
 	windowSize := am.window.Content().Size()
-	popupWidth := windowSize.Width * 2 / 3
-	popupHeight := windowSize.Height / 2
+	popupWidth := windowSize.Width * 3 / 4
+	popupHeight := windowSize.Height * 3 / 4
 
 	// Create square close button without text padding
 	closeButton := widget.NewButton("✕", func() {
@@ -762,7 +1304,7 @@ func (am *AddonManager) showDescriptionPopup(addonName, description string) {
 		nil,
 		nil,
 		nil,
-		contentContainer,
+		container.NewScroll(contentContainer),
 	)
 
 	popup := widget.NewModalPopUp(mainContainer, am.window.Canvas())
@@ -775,11 +1317,371 @@ func (am *AddonManager) showDescriptionPopup(addonName, description string) {
 	popup.Show()
 }
 
+// showDependencyIssuesPopup lists the dependency problems found for an addon
+func (am *AddonManager) showDependencyIssuesPopup(addonName string, issues []DependencyIssue) {
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, "• "+issue.String())
+	}
+
+	issuesLabel := widget.NewLabel(strings.Join(lines, "\n"))
+	issuesLabel.Wrapping = fyne.TextWrapWord
+
+	issuesDialog := dialog.NewCustom(fmt.Sprintf("Dependency issues: %s", addonName), "Close", container.NewScroll(issuesLabel), am.window)
+	windowSize := am.window.Content().Size()
+	issuesDialog.Resize(fyne.NewSize(windowSize.Width*3/4, windowSize.Height/2))
+	issuesDialog.Show()
+}
+
+// showCopyToVersionPopup copies or links an addon into another game version's addons folder
+func (am *AddonManager) showCopyToVersionPopup(addon *Addon, versions []*version.GameVersion) {
+	var names []string
+	for _, ver := range versions {
+		names = append(names, ver.DisplayName)
+	}
+	versionSelect := widget.NewSelect(names, nil)
+	versionSelect.SetSelected(names[0])
+
+	const (
+		copyOption = "Copy (each version keeps its own files)"
+		linkOption = "Link (both versions share one folder)"
+	)
+	modeRadio := widget.NewRadioGroup([]string{copyOption, linkOption}, nil)
+	modeRadio.SetSelected(copyOption)
+
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Copy %s to:", addon.DisplayName())),
+		versionSelect,
+		modeRadio,
+	)
+
+	dialog.ShowCustomConfirm("Copy to Version", "Copy", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		target := versions[versionSelect.SelectedIndex()]
+		link := modeRadio.Selected == linkOption
+
+		go func() {
+			targetPath, err := am.CopyAddonToVersion(addon, target, link)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, am.window)
+					return
+				}
+				dialog.ShowInformation("Success", fmt.Sprintf("%s is now installed in %s:\n%s", addon.DisplayName(), target.DisplayName, targetPath), am.window)
+			})
+		}()
+	}, am.window)
+}
+
+// showPinPopup lets the user pin a git addon to a branch, tag or commit, or roll it back
+func (am *AddonManager) showPinPopup(addon *Addon) {
+	var pinDialog dialog.Dialog
+
+	currentText := fmt.Sprintf("Checked out: %s", shortCommit(addon.LocalCommit))
+	if branch := am.getCurrentBranch(addon.Path); branch != "" {
+		currentText += fmt.Sprintf(" on %s", branch)
+	} else {
+		currentText += " (detached)"
+	}
+	if addon.PinnedRef != "" {
+		currentText += fmt.Sprintf("\nPinned to %s %s", addon.PinnedKind, addon.PinnedRef)
+	}
+	currentLabel := widget.NewLabel(currentText)
+
+	refSelect := widget.NewSelectEntry(nil)
+	refSelect.SetPlaceHolder("Loading...")
+
+	var branches, tags []string
+	kindSelect := widget.NewSelect([]string{PinBranch, PinTag, PinCommit}, func(kind string) {
+		refSelect.SetText("")
+		switch kind {
+		case PinBranch:
+			refSelect.SetOptions(branches)
+			refSelect.SetPlaceHolder("Branch name")
+		case PinTag:
+			refSelect.SetOptions(tags)
+			refSelect.SetPlaceHolder("Tag name")
+		case PinCommit:
+			refSelect.SetOptions(nil)
+			refSelect.SetPlaceHolder("Commit hash")
+		}
+	})
+
+	// Run a pin operation in the background and reopen the addon manager once it's done
+	runPinAction := func(title string, action func() error) {
+		pinDialog.Hide()
+		progressDialog := dialog.NewProgressInfinite(title, fmt.Sprintf("Updating %s...", addon.Name), am.window)
+		progressDialog.Show()
+
+		go func() {
+			err := action()
+			fyne.Do(func() {
+				progressDialog.Hide()
+				if err != nil {
+					dialog.ShowError(err, am.window)
+					return
+				}
+				am.refreshAddonManager()
+			})
+		}()
+	}
+
+	pinButton := widget.NewButton("Pin", func() {
+		kind, ref := kindSelect.Selected, refSelect.Text
+		runPinAction("Pinning addon", func() error {
+			return am.PinAddon(addon, kind, ref)
+		})
+	})
+	pinButton.Importance = widget.HighImportance
+
+	unpinButton := widget.NewButton("Unpin", func() {
+		runPinAction("Unpinning addon", func() error {
+			return am.UnpinAddon(addon)
+		})
+	})
+	unpinButton.Importance = widget.MediumImportance
+	if addon.PinnedRef == "" {
+		unpinButton.Disable()
+	}
+
+	rollbackButton := widget.NewButton(fmt.Sprintf("Roll Back to %s", shortCommit(addon.PreviousCommit)), func() {
+		runPinAction("Rolling back addon", func() error {
+			return am.RollbackAddon(addon)
+		})
+	})
+	rollbackButton.Importance = widget.WarningImportance
+	if addon.PreviousCommit == "" {
+		rollbackButton.Disable()
+	}
+
+	rollbackNote := widget.NewLabel("Rolling back pins the addon to that commit until you unpin it.")
+	rollbackNote.Wrapping = fyne.TextWrapWord
+	rollbackNote.TextStyle = fyne.TextStyle{Italic: true}
+
+	content := container.NewVBox(
+		currentLabel,
+		widget.NewSeparator(),
+		widget.NewForm(
+			widget.NewFormItem("Pin to", kindSelect),
+			widget.NewFormItem("Ref", refSelect),
+		),
+		container.NewHBox(pinButton, unpinButton, rollbackButton),
+		rollbackNote,
+	)
+
+	pinDialog = dialog.NewCustom(fmt.Sprintf("Version: %s", addon.DisplayName()), "Close", content, am.window)
+	pinDialog.Resize(fyne.NewSize(500, 300))
+	pinDialog.Show()
+
+	go func() {
+		loadedBranches, err := am.ListBranches(addon)
+		if err != nil {
+			debug.Printf("Failed to list branches for %s: %v", addon.Name, err)
+		}
+		loadedTags, err := am.ListTags(addon)
+		if err != nil {
+			debug.Printf("Failed to list tags for %s: %v", addon.Name, err)
+		}
+
+		fyne.Do(func() {
+			branches, tags = loadedBranches, loadedTags
+			kind := addon.PinnedKind
+			if kind == "" {
+				kind = PinBranch
+			}
+			kindSelect.SetSelected(kind)
+			if addon.PinnedRef != "" {
+				refSelect.SetText(addon.PinnedRef)
+			}
+		})
+	}()
+}
+
+// showLockfilePopup offers exporting the installed addons to a lockfile or applying one
+func (am *AddonManager) showLockfilePopup() {
+	var lockfileDialog dialog.Dialog
+
+	exportButton := widget.NewButton("Export Lockfile...", func() {
+		lockfileDialog.Hide()
+		am.exportLockfile()
+	})
+	exportButton.Importance = widget.HighImportance
+
+	applyButton := widget.NewButton("Apply Lockfile...", func() {
+		lockfileDialog.Hide()
+		am.chooseLockfileToApply()
+	})
+	applyButton.Importance = widget.MediumImportance
+
+	infoLabel := widget.NewLabel("A lockfile records every installed addon with its source, branch and exact commit or archive hash. Share it so everyone runs the same addon versions.")
+	infoLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(infoLabel, widget.NewSeparator(), container.NewHBox(exportButton, applyButton))
+	lockfileDialog = dialog.NewCustom("Addon Lockfile", "Close", content, am.window)
+	lockfileDialog.Resize(fyne.NewSize(500, 200))
+	lockfileDialog.Show()
+}
+
+func (am *AddonManager) exportLockfile() {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, am.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		lockfile := am.BuildLockfile()
+		if err := lockfile.Write(writer); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to write lockfile: %v", err), am.window)
+			return
+		}
+		debug.Printf("Exported lockfile with %d addons to %s", len(lockfile.Addons), writer.URI().Path())
+		dialog.ShowInformation("Lockfile Exported", fmt.Sprintf("Saved %d addons to %s", len(lockfile.Addons), writer.URI().Name()), am.window)
+	}, am.window)
+	saveDialog.SetFileName("addons.lock.json")
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	saveDialog.Resize(am.window.Canvas().Size())
+	saveDialog.Show()
+}
+
+func (am *AddonManager) chooseLockfileToApply() {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, am.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		lockfile, err := ReadLockfile(reader)
+		if err != nil {
+			dialog.ShowError(err, am.window)
+			return
+		}
+		am.showLockfilePlan(lockfile)
+	}, am.window)
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	openDialog.Resize(am.window.Canvas().Size())
+	openDialog.Show()
+}
+
+// showLockfilePlan shows the changes a lockfile would make and applies them once confirmed
+func (am *AddonManager) showLockfilePlan(lockfile *Lockfile) {
+	changes := am.PlanLockfile(lockfile)
+	if len(changes) == 0 {
+		dialog.ShowInformation("Lockfile", "Your addons already match the lockfile.", am.window)
+		return
+	}
+
+	var lines []string
+	removals := 0
+	for _, change := range changes {
+		lines = append(lines, change.String())
+		if change.Action == LockActionRemove {
+			removals++
+		}
+	}
+
+	changesLabel := widget.NewLabel(strings.Join(lines, "\n"))
+	changesLabel.Wrapping = fyne.TextWrapWord
+
+	// Deleting addons is opt-in, a shared lockfile rarely lists everything a player uses
+	removeCheck := widget.NewCheck(fmt.Sprintf("Remove %d addons that aren't in the lockfile", removals), nil)
+	if removals == 0 {
+		removeCheck.Hide()
+	}
+
+	content := container.NewBorder(nil, removeCheck, nil, nil, container.NewScroll(changesLabel))
+	if lockfile.WoWVersion != "" && am.gameVersion != nil && lockfile.WoWVersion != am.gameVersion.WoWVersion {
+		warningLabel := widget.NewLabel(fmt.Sprintf("⚠️ This lockfile was created for WoW %s, but the current version is %s.", lockfile.WoWVersion, am.gameVersion.WoWVersion))
+		warningLabel.Wrapping = fyne.TextWrapWord
+		content = container.NewBorder(warningLabel, removeCheck, nil, nil, container.NewScroll(changesLabel))
+	}
+
+	planDialog := dialog.NewCustomConfirm("Apply Lockfile", "Apply", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		var selected []LockChange
+		for _, change := range changes {
+			if change.Action == LockActionRemove && !removeCheck.Checked {
+				continue
+			}
+			selected = append(selected, change)
+		}
+		am.applyLockfileChanges(selected)
+	}, am.window)
+
+	windowSize := am.window.Content().Size()
+	planDialog.Resize(fyne.NewSize(windowSize.Width*3/4, windowSize.Height*2/3))
+	planDialog.Show()
+}
+
+func (am *AddonManager) applyLockfileChanges(changes []LockChange) {
+	loadingText := widget.NewLabel("Applying lockfile...")
+	loadingText.Alignment = fyne.TextAlignCenter
+
+	progressBar := widget.NewProgressBarInfinite()
+	progressBar.Start()
+
+	loadingContent := container.NewVBox(
+		widget.NewLabel("Applying Lockfile"),
+		widget.NewSeparator(),
+		loadingText,
+		progressBar,
+	)
+
+	loadingPopup := widget.NewModalPopUp(container.NewPadded(loadingContent), am.window.Canvas())
+	loadingPopup.Resize(fyne.NewSize(300, 150))
+	loadingPopup.Show()
+
+	go func() {
+		am.snapshotBeforeUpdate()
+		errs := am.ApplyLockfile(context.Background(), changes, func(message string) {
+			fyne.Do(func() {
+				loadingText.SetText(message)
+			})
+		})
+
+		fyne.Do(func() {
+			progressBar.Stop()
+			loadingPopup.Hide()
+
+			if len(errs) > 0 {
+				var lines []string
+				for _, err := range errs {
+					lines = append(lines, "• "+err.Error())
+				}
+				dialog.ShowInformation("Lockfile Results", fmt.Sprintf("Applied %d of %d changes.\n\nFailed:\n%s", len(changes)-len(errs), len(changes), strings.Join(lines, "\n")), am.window)
+			} else {
+				dialog.ShowInformation("Success", fmt.Sprintf("Applied %d changes from the lockfile.", len(changes)), am.window)
+			}
+			am.refreshAddonManager()
+		})
+	}()
+}
+
+// valueOrDash returns value, or a dash placeholder when it is empty
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
 func (am *AddonManager) showAddAddonPopup() {
-	titleText := widget.NewLabel("Add Addon from Repository")
+	titleText := widget.NewLabel("Add Addon")
 	titleText.TextStyle = fyne.TextStyle{Bold: true}
 
-	instructionText := widget.NewLabel("Enter the GitHub or GitLab repository URL:")
+	instructionText := widget.NewLabel("Enter a GitHub or GitLab repository URL, or a link to a .zip file:")
 	instructionText.TextStyle = fyne.TextStyle{Italic: true}
 
 	urlEntry := widget.NewEntry()
@@ -802,15 +1704,39 @@ func (am *AddonManager) showAddAddonPopup() {
 			dialog.ShowError(fmt.Errorf("please enter a repository URL"), am.window)
 			return
 		}
-		am.installAddonFromRepo(url)
+		am.installAddonFromSource(url)
 	})
 	installButton.Importance = widget.HighImportance
 
+	zipFileButton := widget.NewButton("From Zip File...", func() {
+		zipDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, am.window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			zipPath := reader.URI().Path()
+			reader.Close()
+			am.installAddonFromSource(zipPath)
+		}, am.window)
+		zipDialog.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+		zipDialog.Resize(am.window.Canvas().Size())
+		zipDialog.Show()
+	})
+	zipFileButton.Importance = widget.MediumImportance
+
 	addMultipleButton := widget.NewButton("Add Multiple", func() {
 		// This will be set when the popup is created
 	})
 	addMultipleButton.Importance = widget.MediumImportance
 
+	catalogButton := widget.NewButton("Browse Catalog...", func() {
+		// This will be set when the popup is created
+	})
+	catalogButton.Importance = widget.MediumImportance
+
 	contentContainer := container.NewVBox(
 		titleText,
 		widget.NewSeparator(),
@@ -819,7 +1745,7 @@ func (am *AddonManager) showAddAddonPopup() {
 		widget.NewSeparator(),
 		container.NewCenter(findAddonsButton),
 		widget.NewSeparator(),
-		container.NewHBox(installButton, addMultipleButton),
+		container.NewHBox(installButton, addMultipleButton, zipFileButton, catalogButton),
 	)
 
 	windowSize := am.window.Content().Size()
@@ -865,6 +1791,11 @@ func (am *AddonManager) showAddAddonPopup() {
 		am.showAddMultipleAddonsPopup()
 	}
 
+	catalogButton.OnTapped = func() {
+		popup.Hide()
+		am.showCatalogPopup()
+	}
+
 	popup.Show()
 }
 
@@ -872,7 +1803,7 @@ func (am *AddonManager) showAddMultipleAddonsPopup() {
 	titleText := widget.NewLabel("Add Multiple Addons")
 	titleText.TextStyle = fyne.TextStyle{Bold: true}
 
-	instructionText := widget.NewLabel("Enter one repository URL per line:")
+	instructionText := widget.NewLabel("Enter one repository or .zip URL per line:")
 	instructionText.TextStyle = fyne.TextStyle{Italic: true}
 
 	urlsEntry := widget.NewMultiLineEntry()
@@ -960,64 +1891,33 @@ func (am *AddonManager) showAddMultipleAddonsPopup() {
 }
 
 func (am *AddonManager) installMultipleAddons(urls []string) {
-	// Create loading dialog with updatable text
-	loadingText := widget.NewLabel(fmt.Sprintf("Installing %d addons...", len(urls)))
-	loadingText.Alignment = fyne.TextAlignCenter
-
-	progressBar := widget.NewProgressBarInfinite()
-	progressBar.Start()
-
-	loadingContent := container.NewVBox(
-		widget.NewLabel("Installing Addons"),
-		widget.NewSeparator(),
-		loadingText,
-		progressBar,
-	)
-
-	loadingPopup := widget.NewModalPopUp(container.NewPadded(loadingContent), am.window.Canvas())
-	loadingPopup.Resize(fyne.NewSize(300, 150))
-	loadingPopup.Show()
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := am.newTaskProgress(fmt.Sprintf("Installing %d addons", len(urls)), urls, cancel)
 
 	go func() {
-		defer func() {
-			progressBar.Stop()
-			loadingPopup.Hide()
-		}()
-
-		installed := 0
-		failed := 0
-
-		for i, url := range urls {
-			// Update progress
-			fyne.Do(func() {
-				loadingText.SetText(fmt.Sprintf("Installing addon %d/%d...", i+1, len(urls)))
-			})
+		defer cancel()
 
-			if err := am.cloneRepository(url); err != nil {
-				debug.Printf("Failed to install %s: %v", url, err)
-				failed++
-			} else {
-				installed++
-			}
-		}
-
-		// Show results
-		message := fmt.Sprintf("Installation complete!\nInstalled: %d\nFailed: %d", installed, failed)
-		if failed > 0 {
-			dialog.ShowInformation("Installation Results", message, am.window)
-		} else {
-			dialog.ShowInformation("Success", message, am.window)
-		}
+		concurrency, timeout := addonPoolSettings()
+		results := runTasks(ctx, urls, concurrency, timeout, func(taskCtx context.Context, i int) (string, error) {
+			return "", am.installSource(taskCtx, urls[i])
+		}, progress.started, progress.finished)
 
-		// Refresh the addon manager to show new addons
 		fyne.Do(func() {
+			progress.hide()
+			am.showTaskResults("Installation Results", results)
+			// Refresh the addon manager to show new addons
 			am.refreshAddonManager()
 		})
 	}()
 }
 
-func (am *AddonManager) installAddonFromRepo(repoURL string) {
-	progressDialog := dialog.NewProgressInfinite("Installing addon", "Cloning repository...", am.window)
+func (am *AddonManager) installAddonFromSource(source string) {
+	progressMessage := "Cloning repository..."
+	if isZipSource(source) {
+		progressMessage = "Installing from zip archive..."
+	}
+
+	progressDialog := dialog.NewProgressInfinite("Installing addon", progressMessage, am.window)
 	progressDialog.Show()
 
 	go func() {
@@ -1027,7 +1927,7 @@ func (am *AddonManager) installAddonFromRepo(repoURL string) {
 			})
 		}()
 
-		if err := am.cloneRepository(repoURL); err != nil {
+		if err := am.installSource(context.Background(), source); err != nil {
 			fyne.Do(func() {
 				dialog.ShowError(fmt.Errorf("failed to install addon: %v", err), am.window)
 			})
@@ -1040,19 +1940,30 @@ func (am *AddonManager) installAddonFromRepo(repoURL string) {
 	}()
 }
 
-func (am *AddonManager) cloneRepository(repoURL string) error {
-	if paths.TurtlewowPath == "" {
-		return fmt.Errorf("game path not set")
+// installSource installs an addon from either a git repository or a zip archive
+func (am *AddonManager) installSource(ctx context.Context, source string) error {
+	if isZipSource(source) {
+		_, err := am.InstallFromZip(ctx, source)
+		return err
 	}
+	return am.cloneRepository(ctx, source)
+}
 
-	addonsPath := filepath.Join(paths.TurtlewowPath, "Interface", "Addons")
-
+func (am *AddonManager) cloneRepository(ctx context.Context, repoURL string) error {
 	// Extract addon name from URL
 	parts := strings.Split(strings.TrimSuffix(repoURL, ".git"), "/")
 	if len(parts) < 2 {
 		return fmt.Errorf("invalid repository URL")
 	}
-	addonName := parts[len(parts)-1]
+	return am.cloneRepositoryAs(ctx, repoURL, parts[len(parts)-1])
+}
+
+// cloneRepositoryAs clones a repository into the addons directory under the given folder name
+func (am *AddonManager) cloneRepositoryAs(ctx context.Context, repoURL, addonName string) error {
+	addonsPath, err := am.addonsPath()
+	if err != nil {
+		return err
+	}
 
 	addonPath := filepath.Join(addonsPath, addonName)
 
@@ -1063,19 +1974,30 @@ func (am *AddonManager) cloneRepository(repoURL string) error {
 
 	debug.Printf("Cloning repository %s to %s", repoURL, addonPath)
 
-	cmd := exec.Command("git", "clone", repoURL, addonPath)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		debug.Printf("Git clone failed: %s", string(output))
-		return fmt.Errorf("git clone failed: %v", err)
+	if err := am.git().Clone(ctx, repoURL, addonPath); err != nil {
+		// Don't leave a half-cloned folder behind
+		os.RemoveAll(addonPath)
+		return err
 	}
 
-	debug.Printf("Successfully cloned repository: %s", string(output))
 	return nil
 }
 
 func (am *AddonManager) confirmDeleteAddon(addon *Addon) {
 	message := fmt.Sprintf("Are you sure you want to delete the addon '%s'?\n\nThis action cannot be undone.", addon.Name)
+	if addon.Linked {
+		message = fmt.Sprintf("Remove the link to '%s'?\n\nThe addon stays installed in the folder it links to.", addon.Name)
+	}
+	if siblings := packSiblings(addon); len(siblings) > 0 {
+		var names []string
+		for _, sibling := range siblings {
+			names = append(names, filepath.Base(sibling))
+		}
+		message += fmt.Sprintf("\n\nThese folders were installed from the same archive and will be deleted too:\n%s", strings.Join(names, ", "))
+	}
+	if dependents := am.Dependents(addon.Name); len(dependents) > 0 {
+		message += fmt.Sprintf("\n\nWarning: the following addons require %s and will stop loading:\n%s", addon.Name, strings.Join(dependents, ", "))
+	}
 
 	confirmDialog := dialog.NewConfirm("Confirm Deletion", message, func(confirmed bool) {
 		if confirmed {
@@ -0,0 +1,119 @@
+package addons
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	SourceTypeGit = "git"
+	SourceTypeZip = "zip"
+)
+
+// AddonSource records where an installed addon came from so it can be updated later
+type AddonSource struct {
+	Type        string    `json:"type"`
+	URL         string    `json:"url"`
+	Hash        string    `json:"hash,omitempty"`
+	Folders     []string  `json:"folders,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+
+	// Git addons only: the ref the addon is pinned to and the commit before the last update
+	PinnedRef      string `json:"pinned_ref,omitempty"`
+	PinnedKind     string `json:"pinned_kind,omitempty"`
+	PreviousCommit string `json:"previous_commit,omitempty"`
+}
+
+// addonStore is the on-disk record of addon sources, keyed by addon directory
+type addonStore struct {
+	Sources map[string]*AddonSource `json:"sources"`
+}
+
+var addonStoreMutex sync.Mutex
+
+func getAddonStorePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "TurtleSilicon", "addons.json"), nil
+}
+
+// loadAddonStore reads the addon store, returning an empty store if none exists yet
+func loadAddonStore() *addonStore {
+	store := &addonStore{Sources: make(map[string]*AddonSource)}
+
+	path, err := getAddonStorePath()
+	if err != nil {
+		return store
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return store
+	}
+	if err := json.Unmarshal(data, store); err != nil || store.Sources == nil {
+		return &addonStore{Sources: make(map[string]*AddonSource)}
+	}
+	return store
+}
+
+func (s *addonStore) save() error {
+	path, err := getAddonStorePath()
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// getAddonSource returns the recorded source for an addon directory, or nil if unknown
+func getAddonSource(addonPath string) *AddonSource {
+	addonStoreMutex.Lock()
+	defer addonStoreMutex.Unlock()
+
+	return loadAddonStore().Sources[filepath.Clean(addonPath)]
+}
+
+// updateAddonStore loads the store, applies fn and saves the result
+func updateAddonStore(fn func(store *addonStore)) error {
+	addonStoreMutex.Lock()
+	defer addonStoreMutex.Unlock()
+
+	store := loadAddonStore()
+	fn(store)
+	return store.save()
+}
+
+// setAddonSource records the source for an addon directory
+func setAddonSource(addonPath string, source *AddonSource) error {
+	return updateAddonStore(func(store *addonStore) {
+		store.Sources[filepath.Clean(addonPath)] = source
+	})
+}
+
+// removeAddonSource forgets the recorded source for an addon directory
+func removeAddonSource(addonPath string) error {
+	return updateAddonStore(func(store *addonStore) {
+		delete(store.Sources, filepath.Clean(addonPath))
+	})
+}
+
+// updateGitSource applies fn to the recorded source of a git addon, creating it if needed
+func updateGitSource(addonPath, remoteURL string, fn func(source *AddonSource)) error {
+	return updateAddonStore(func(store *addonStore) {
+		key := filepath.Clean(addonPath)
+		source := store.Sources[key]
+		if source == nil || source.Type != SourceTypeGit {
+			source = &AddonSource{Type: SourceTypeGit, URL: remoteURL, InstalledAt: time.Now()}
+			store.Sources[key] = source
+		}
+		fn(source)
+	})
+}
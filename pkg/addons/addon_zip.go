@@ -0,0 +1,397 @@
+package addons
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/download"
+	"turtlesilicon/pkg/utils"
+)
+
+// zipAddonRoot is a folder inside a zip archive that should be installed as an addon
+type zipAddonRoot struct {
+	dir  string // directory inside the archive ("." for the archive root)
+	name string // folder name the addon must be installed under
+}
+
+// isZipSource reports whether source points at a zip archive rather than a git repository
+func isZipSource(source string) bool {
+	source = strings.TrimSpace(source)
+
+	if parsedURL, err := url.Parse(source); err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") {
+		return strings.HasSuffix(strings.ToLower(parsedURL.Path), ".zip")
+	}
+
+	return strings.HasSuffix(strings.ToLower(source), ".zip") && utils.PathExists(source)
+}
+
+// InstallFromZip installs the addon folders contained in a local or remote zip archive
+// and returns the names of the installed folders
+func (am *AddonManager) InstallFromZip(ctx context.Context, source string) ([]string, error) {
+	return am.installZip(ctx, strings.TrimSpace(source), false)
+}
+
+// installZip fetches and extracts a zip archive into the addons directory. When replace is
+// true existing folders with the same name are overwritten, which is how zip addons update.
+func (am *AddonManager) installZip(ctx context.Context, source string, replace bool) ([]string, error) {
+	archivePath, cleanup, err := fetchZip(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return am.installZipFrom(archivePath, source, replace)
+}
+
+// installZipFrom extracts an already fetched archive into the addons directory, recording
+// source as where the addon came from
+func (am *AddonManager) installZipFrom(archivePath, source string, replace bool) ([]string, error) {
+	addonsPath, err := am.addonsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := hashFile(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash archive: %v", err)
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %v", err)
+	}
+	defer reader.Close()
+
+	roots, err := findZipAddonRoots(reader.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []string
+	for _, root := range roots {
+		folders = append(folders, root.name)
+		if !replace && utils.PathExists(filepath.Join(addonsPath, root.name)) {
+			return nil, fmt.Errorf("addon '%s' already exists", root.name)
+		}
+	}
+
+	stagingPath, err := os.MkdirTemp(addonsPath, ".turtlesilicon-zip-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %v", err)
+	}
+	defer os.RemoveAll(stagingPath)
+
+	for _, root := range roots {
+		debug.Printf("Extracting %s from %s as %s", root.dir, source, root.name)
+		if err := extractZipRoot(reader.File, root.dir, filepath.Join(stagingPath, root.name)); err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %v", root.name, err)
+		}
+	}
+
+	for _, root := range roots {
+		targetPath := filepath.Join(addonsPath, root.name)
+		if err := replaceDir(filepath.Join(stagingPath, root.name), targetPath); err != nil {
+			return nil, fmt.Errorf("failed to install %s: %v", root.name, err)
+		}
+
+		if err := setAddonSource(targetPath, &AddonSource{
+			Type:        SourceTypeZip,
+			URL:         source,
+			Hash:        hash,
+			Folders:     folders,
+			InstalledAt: time.Now(),
+		}); err != nil {
+			debug.Printf("Warning: failed to record source for %s: %v", root.name, err)
+		}
+	}
+
+	debug.Printf("Installed %d addon folder(s) from %s: %s", len(folders), source, strings.Join(folders, ", "))
+	return folders, nil
+}
+
+// packSiblings returns the other folders installed from the same archive as a zip addon
+func packSiblings(addon *Addon) []string {
+	if addon.Linked {
+		return nil
+	}
+	store := loadAddonStore()
+	source := store.Sources[filepath.Clean(addon.Path)]
+	if source == nil || source.Type != SourceTypeZip {
+		return nil
+	}
+
+	var siblings []string
+	for _, folder := range source.Folders {
+		path := filepath.Join(filepath.Dir(addon.Path), folder)
+		if filepath.Clean(path) == filepath.Clean(addon.Path) || folder != filepath.Base(folder) {
+			continue
+		}
+		// Skip folders that were replaced by something else since
+		if sibling := store.Sources[filepath.Clean(path)]; sibling == nil || sibling.Type != SourceTypeZip || sibling.URL != source.URL {
+			continue
+		}
+		siblings = append(siblings, path)
+	}
+	return siblings
+}
+
+// updateZipAddon re-downloads the archive an addon was installed from and reinstalls it if it changed
+func (am *AddonManager) updateZipAddon(ctx context.Context, addon *Addon) error {
+	source := getAddonSource(addon.Path)
+	if source == nil || source.URL == "" {
+		return fmt.Errorf("no recorded source for addon %s", addon.Name)
+	}
+
+	archivePath, cleanup, err := fetchZip(ctx, source.URL)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	hash, err := hashFile(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to hash archive: %v", err)
+	}
+	if hash == source.Hash {
+		debug.Printf("Addon %s is already up to date with %s", addon.Name, source.URL)
+		return nil
+	}
+
+	// Install the archive that was just hashed rather than downloading it again
+	_, err = am.installZipFrom(archivePath, source.URL, true)
+	return err
+}
+
+// fetchZip returns a local path to the archive, downloading it first if source is a URL
+func fetchZip(ctx context.Context, source string) (string, func(), error) {
+	parsedURL, err := url.Parse(source)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		if !utils.PathExists(source) {
+			return "", nil, fmt.Errorf("zip archive not found: %s", source)
+		}
+		return source, func() {}, nil
+	}
+
+	tempDir, err := os.MkdirTemp("", "TurtleSilicon-addon-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+
+	debug.Printf("Downloading addon archive: %s", source)
+	archivePath := filepath.Join(tempDir, "addon.zip")
+	if err := download.File(ctx, source, archivePath, download.Options{}); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return archivePath, cleanup, nil
+}
+
+// findZipAddonRoots locates the addon folders in an archive by their .toc files. Nested
+// layouts such as Addon-master/Addon/Addon.toc are flattened, folders whose name doesn't
+// match their .toc are renamed, and every top-level addon of a multi-folder pack is returned.
+func findZipAddonRoots(files []*zip.File) ([]zipAddonRoot, error) {
+	tocsByDir := make(map[string][]string)
+	for _, file := range files {
+		name := file.Name
+		if isZipJunk(name) || file.FileInfo().IsDir() {
+			continue
+		}
+		if strings.EqualFold(path.Ext(name), ".toc") {
+			dir := path.Dir(name)
+			tocsByDir[dir] = append(tocsByDir[dir], strings.TrimSuffix(path.Base(name), path.Ext(name)))
+		}
+	}
+
+	if len(tocsByDir) == 0 {
+		return nil, fmt.Errorf("no addon .toc files found in archive")
+	}
+
+	dirs := make([]string, 0, len(tocsByDir))
+	for dir := range tocsByDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		depthI, depthJ := zipDirDepth(dirs[i]), zipDirDepth(dirs[j])
+		if depthI != depthJ {
+			return depthI < depthJ
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	var roots []zipAddonRoot
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		nested := false
+		for _, root := range roots {
+			if root.dir == "." || strings.HasPrefix(dir, root.dir+"/") {
+				nested = true
+				break
+			}
+		}
+		if nested {
+			continue
+		}
+
+		name := zipAddonName(dir, tocsByDir[dir])
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("archive contains more than one addon named %s", name)
+		}
+		seen[strings.ToLower(name)] = true
+		roots = append(roots, zipAddonRoot{dir: dir, name: name})
+	}
+
+	return roots, nil
+}
+
+// zipAddonName picks the folder name for an addon: the directory name if it matches one of
+// its .toc files, otherwise the shortest .toc name (Addon.toc over Addon_TBC.toc)
+func zipAddonName(dir string, tocs []string) string {
+	base := path.Base(dir)
+	for _, toc := range tocs {
+		if strings.EqualFold(toc, base) {
+			return base
+		}
+	}
+
+	sort.Slice(tocs, func(i, j int) bool {
+		if len(tocs[i]) != len(tocs[j]) {
+			return len(tocs[i]) < len(tocs[j])
+		}
+		return tocs[i] < tocs[j]
+	})
+	return tocs[0]
+}
+
+func zipDirDepth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// isZipJunk filters out macOS metadata that some archivers add
+func isZipJunk(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._") || path.Base(name) == ".DS_Store"
+}
+
+// extractZipRoot extracts everything below rootDir in the archive into destPath
+func extractZipRoot(files []*zip.File, rootDir, destPath string) error {
+	prefix := ""
+	if rootDir != "." {
+		prefix = rootDir + "/"
+	}
+
+	if err := os.MkdirAll(destPath, 0755); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if isZipJunk(file.Name) || !strings.HasPrefix(file.Name, prefix) {
+			continue
+		}
+
+		relPath := strings.TrimPrefix(file.Name, prefix)
+		if relPath == "" {
+			continue
+		}
+
+		// Guard against archive entries escaping the destination directory
+		cleanRel := filepath.Clean(filepath.FromSlash(relPath))
+		if filepath.IsAbs(cleanRel) || cleanRel == ".." || strings.HasPrefix(cleanRel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %s escapes the addon directory", file.Name)
+		}
+		targetPath := filepath.Join(destPath, cleanRel)
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := extractZipFile(file, targetPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractZipFile(file *zip.File, targetPath string) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return err
+	}
+
+	source, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	// Keep the archive's permission bits, but never make files group or world writable
+	mode := file.Mode().Perm() & 0755
+	if mode == 0 {
+		mode = 0644
+	}
+	destination, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+
+	_, err = io.Copy(destination, source)
+	return err
+}
+
+// replaceDir moves srcPath to destPath, replacing any existing directory at destPath
+func replaceDir(srcPath, destPath string) error {
+	backupPath := ""
+	if utils.PathExists(destPath) {
+		backupPath = destPath + ".old"
+		os.RemoveAll(backupPath)
+		if err := os.Rename(destPath, backupPath); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(srcPath, destPath); err != nil {
+		if backupPath != "" {
+			os.Rename(backupPath, destPath)
+		}
+		return err
+	}
+
+	if backupPath != "" {
+		os.RemoveAll(backupPath)
+	}
+	return nil
+}
+
+// hashFile returns the hex encoded SHA-256 of a file
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,154 @@
+package addons
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"turtlesilicon/pkg/utils"
+)
+
+func TestFindZipAddonRoots(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  []zipAddonRoot
+	}{
+		{
+			name:  "nested github layout",
+			files: []string{"pfQuest-master/pfQuest/pfQuest.toc", "pfQuest-master/pfQuest/init.lua", "pfQuest-master/README.md"},
+			want:  []zipAddonRoot{{dir: "pfQuest-master/pfQuest", name: "pfQuest"}},
+		},
+		{
+			name:  "folder name does not match toc",
+			files: []string{"Atlas-master/Atlas.toc", "Atlas-master/Atlas_TBC.toc", "Atlas-master/Libs/LibStub/LibStub.toc"},
+			want:  []zipAddonRoot{{dir: "Atlas-master", name: "Atlas"}},
+		},
+		{
+			name:  "multi-folder pack",
+			files: []string{"Pack/Bagnon/Bagnon.toc", "Pack/Bagnon_Core/Bagnon_Core.toc", "__MACOSX/Pack/._Bagnon"},
+			want:  []zipAddonRoot{{dir: "Pack/Bagnon", name: "Bagnon"}, {dir: "Pack/Bagnon_Core", name: "Bagnon_Core"}},
+		},
+		{
+			name:  "toc at archive root",
+			files: []string{"Clique.toc", "Clique.lua"},
+			want:  []zipAddonRoot{{dir: ".", name: "Clique"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findZipAddonRoots(buildZip(t, tt.files))
+			if err != nil {
+				t.Fatalf("findZipAddonRoots() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("findZipAddonRoots() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("root %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := findZipAddonRoots(buildZip(t, []string{"README.md"})); err == nil {
+		t.Errorf("findZipAddonRoots() without .toc files should fail")
+	}
+}
+
+func buildZip(t *testing.T, names []string) []*zip.File {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, name := range names {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to read zip: %v", err)
+	}
+	return reader.File
+}
+
+func TestExtractZipFileKeepsMode(t *testing.T) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, mode := range map[string]os.FileMode{"run.sh": 0777, "Addon.lua": 0644, "plain.txt": 0} {
+		header := &zip.FileHeader{Name: name}
+		if mode != 0 {
+			header.SetMode(mode)
+		}
+		if _, err := writer.CreateHeader(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writer.Close()
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	want := map[string]os.FileMode{"run.sh": 0755, "Addon.lua": 0644, "plain.txt": 0644}
+	for _, file := range reader.File {
+		target := filepath.Join(dir, file.Name)
+		if err := extractZipFile(file, target); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The umask may only take bits away
+		if got := info.Mode().Perm(); got&^want[file.Name] != 0 || got&0700 != want[file.Name]&0700 {
+			t.Errorf("%s has mode %o, want %o", file.Name, got, want[file.Name])
+		}
+	}
+}
+
+func TestDeleteAddonRemovesPackFolders(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	addonsPath := t.TempDir()
+	for _, folder := range []string{"Bagnon", "Bagnon_Core", "Bagnon_Forever"} {
+		os.MkdirAll(filepath.Join(addonsPath, folder), 0755)
+	}
+	folders := []string{"Bagnon", "Bagnon_Core", "Bagnon_Forever"}
+	setAddonSource(filepath.Join(addonsPath, "Bagnon"), &AddonSource{Type: SourceTypeZip, URL: "https://example.com/bagnon.zip", Folders: folders})
+	setAddonSource(filepath.Join(addonsPath, "Bagnon_Core"), &AddonSource{Type: SourceTypeZip, URL: "https://example.com/bagnon.zip", Folders: folders})
+	// Reinstalled from elsewhere since, so it must stay
+	setAddonSource(filepath.Join(addonsPath, "Bagnon_Forever"), &AddonSource{Type: SourceTypeZip, URL: "https://example.com/other.zip"})
+
+	am := &AddonManager{addons: []Addon{
+		{Name: "Bagnon", Path: filepath.Join(addonsPath, "Bagnon")},
+		{Name: "Bagnon_Core", Path: filepath.Join(addonsPath, "Bagnon_Core")},
+		{Name: "Bagnon_Forever", Path: filepath.Join(addonsPath, "Bagnon_Forever")},
+	}}
+	if err := am.DeleteAddon(&am.addons[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	for folder, wantExists := range map[string]bool{"Bagnon": false, "Bagnon_Core": false, "Bagnon_Forever": true} {
+		if exists := utils.DirExists(filepath.Join(addonsPath, folder)); exists != wantExists {
+			t.Errorf("%s exists = %v, want %v", folder, exists, wantExists)
+		}
+	}
+	if len(am.addons) != 1 || am.addons[0].Name != "Bagnon_Forever" {
+		t.Errorf("addons after delete = %+v", am.addons)
+	}
+	if source := loadAddonStore().Sources[filepath.Clean(filepath.Join(addonsPath, "Bagnon_Core"))]; source != nil {
+		t.Errorf("source of Bagnon_Core still recorded")
+	}
+}
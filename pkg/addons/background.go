@@ -0,0 +1,372 @@
+package addons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/launcher"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/version"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	backgroundCheckPoll    = 10 * time.Minute
+	backgroundCheckTimeout = 15 * time.Minute
+)
+
+// updateCacheEntry is the result of the last update check of one game version
+type updateCacheEntry struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Updatable []string  `json:"updatable"`
+}
+
+// updateCache keeps update check results between runs, keyed by game version
+type updateCache struct {
+	Versions map[string]*updateCacheEntry `json:"versions"`
+}
+
+var (
+	updateCacheMutex      sync.Mutex
+	updateCountListener   func()
+	backgroundCheckActive atomic.Bool
+)
+
+func getUpdateCachePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "TurtleSilicon", "addon_updates.json"), nil
+}
+
+// loadUpdateCache reads the update cache, returning an empty cache if none exists yet
+func loadUpdateCache() *updateCache {
+	cache := &updateCache{Versions: make(map[string]*updateCacheEntry)}
+
+	path, err := getUpdateCachePath()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, cache); err != nil || cache.Versions == nil {
+		return &updateCache{Versions: make(map[string]*updateCacheEntry)}
+	}
+	return cache
+}
+
+func (c *updateCache) save() error {
+	path, err := getUpdateCachePath()
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// updateUpdateCache loads the cache, applies fn, saves it and notifies the listener
+func updateUpdateCache(fn func(cache *updateCache)) {
+	updateCacheMutex.Lock()
+	cache := loadUpdateCache()
+	fn(cache)
+	err := cache.save()
+	listener := updateCountListener
+	updateCacheMutex.Unlock()
+
+	if err != nil {
+		debug.Printf("Failed to save addon update cache: %v", err)
+	}
+	if listener != nil {
+		listener()
+	}
+}
+
+// versionKey identifies a game version in the update cache
+func versionKey(gameVersion *version.GameVersion) string {
+	if gameVersion == nil {
+		return "legacy"
+	}
+	return gameVersion.ID
+}
+
+// SetUpdateCountListener registers fn to be called, from any goroutine, whenever cached
+// update results change
+func SetUpdateCountListener(fn func()) {
+	updateCacheMutex.Lock()
+	defer updateCacheMutex.Unlock()
+	updateCountListener = fn
+}
+
+// CachedUpdateCount returns the number of updatable addons found by the last check of a version
+func CachedUpdateCount(gameVersion *version.GameVersion) int {
+	updateCacheMutex.Lock()
+	defer updateCacheMutex.Unlock()
+
+	if entry := loadUpdateCache().Versions[versionKey(gameVersion)]; entry != nil {
+		return len(entry.Updatable)
+	}
+	return 0
+}
+
+// recordUpdateCheck caches which of the scanned addons need updates
+func (am *AddonManager) recordUpdateCheck() {
+	var updatable []string
+	for _, addon := range am.addons {
+		if addon.NeedsUpdate {
+			updatable = append(updatable, addon.Name)
+		}
+	}
+	updateUpdateCache(func(cache *updateCache) {
+		cache.Versions[versionKey(am.gameVersion)] = &updateCacheEntry{CheckedAt: time.Now(), Updatable: updatable}
+	})
+}
+
+// forgetUpdates removes updated addons from the cached results
+func (am *AddonManager) forgetUpdates(results []TaskResult) {
+	updated := make(map[string]bool)
+	for _, result := range results {
+		if result.Err == nil {
+			updated[result.Name] = true
+		}
+	}
+	if len(updated) == 0 {
+		return
+	}
+
+	updateUpdateCache(func(cache *updateCache) {
+		entry := cache.Versions[versionKey(am.gameVersion)]
+		if entry == nil {
+			return
+		}
+		remaining := entry.Updatable[:0]
+		for _, name := range entry.Updatable {
+			if !updated[name] {
+				remaining = append(remaining, name)
+			}
+		}
+		entry.Updatable = remaining
+	})
+}
+
+// StartBackgroundChecker checks the current game version's addons for updates at app start
+// and on the interval set in the preferences, caching the results. If enabled, addons that
+// aren't pinned and have no local changes are updated while the game isn't running.
+// Scheduled SavedVariables snapshots are taken on the same ticker.
+func StartBackgroundChecker(window fyne.Window, currentVersion func() *version.GameVersion) {
+	go func() {
+		SnapshotSavedVariablesIfDue(currentVersion())
+		if prefs, err := utils.LoadPrefs(); err == nil && prefs.AddonCheckOnStart {
+			runBackgroundCheck(window, currentVersion())
+		}
+
+		ticker := time.NewTicker(backgroundCheckPoll)
+		defer ticker.Stop()
+		for range ticker.C {
+			SnapshotSavedVariablesIfDue(currentVersion())
+
+			prefs, err := utils.LoadPrefs()
+			if err != nil || prefs.AddonCheckIntervalHours <= 0 {
+				continue
+			}
+
+			gameVersion := currentVersion()
+			updateCacheMutex.Lock()
+			entry := loadUpdateCache().Versions[versionKey(gameVersion)]
+			updateCacheMutex.Unlock()
+
+			if entry == nil || time.Since(entry.CheckedAt) >= time.Duration(prefs.AddonCheckIntervalHours)*time.Hour {
+				runBackgroundCheck(window, gameVersion)
+			}
+		}
+	}()
+}
+
+// runBackgroundCheck scans a game version's addons for updates without showing any UI
+func runBackgroundCheck(window fyne.Window, gameVersion *version.GameVersion) {
+	if gameVersion == nil || gameVersion.GamePath == "" {
+		return
+	}
+	if !backgroundCheckActive.CompareAndSwap(false, true) {
+		return
+	}
+	defer backgroundCheckActive.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), backgroundCheckTimeout)
+	defer cancel()
+
+	updateCacheMutex.Lock()
+	previous := make(map[string]bool)
+	if entry := loadUpdateCache().Versions[versionKey(gameVersion)]; entry != nil {
+		for _, name := range entry.Updatable {
+			previous[name] = true
+		}
+	}
+	updateCacheMutex.Unlock()
+
+	debug.Printf("Checking addons of %s for updates in the background", gameVersion.DisplayName)
+	am := NewAddonManager(window, gameVersion)
+	if err := am.scanAddonsWithProgress(ctx, nil, true); err != nil {
+		debug.Printf("Background addon check failed: %v", err)
+		return
+	}
+	if ctx.Err() != nil {
+		debug.Printf("Background addon check timed out")
+		return
+	}
+	am.recordUpdateCheck()
+
+	var newUpdates []string
+	for _, addon := range am.addons {
+		if addon.NeedsUpdate && !previous[addon.Name] {
+			newUpdates = append(newUpdates, addon.Name)
+		}
+	}
+
+	if prefs, err := utils.LoadPrefs(); err == nil && prefs.AutoUpdateAddons {
+		if isGameRunning(gameVersion) {
+			debug.Printf("Skipping automatic addon updates while the game is running")
+		} else if updated := am.autoUpdate(ctx); len(updated) > 0 {
+			sendNotification("Addons updated", fmt.Sprintf("Updated %s", strings.Join(updated, ", ")))
+			return
+		}
+	}
+
+	if len(newUpdates) > 0 {
+		sendNotification("Addon updates available", fmt.Sprintf("Updates are available for %s", strings.Join(newUpdates, ", ")))
+	}
+}
+
+// autoUpdate updates every addon that can be updated without user input and returns the
+// names of those that were updated
+func (am *AddonManager) autoUpdate(ctx context.Context) []string {
+	candidates := autoUpdateCandidates(am.addons)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(candidates))
+	for i, addon := range candidates {
+		names[i] = addon.Name
+	}
+
+	am.snapshotBeforeUpdate()
+	concurrency, timeout := addonPoolSettings()
+	results := runTasks(ctx, names, concurrency, timeout, func(taskCtx context.Context, i int) (string, error) {
+		return am.UpdateAddon(taskCtx, &candidates[i], StrategyPull)
+	}, nil, nil)
+	am.forgetUpdates(results)
+
+	var updated []string
+	for _, result := range results {
+		if result.Err == nil {
+			updated = append(updated, result.Name)
+		}
+	}
+	return updated
+}
+
+// autoUpdateCandidates returns the addons that can be updated without user input: git addons
+// with an update that aren't pinned and have no local changes
+func autoUpdateCandidates(addons []Addon) []Addon {
+	var candidates []Addon
+	for _, addon := range addons {
+		if addon.NeedsUpdate && addon.HasGitRepo && addon.PinnedRef == "" && !addon.HasLocalState() {
+			candidates = append(candidates, addon)
+		}
+	}
+	return candidates
+}
+
+// isGameRunning reports whether the game of a version was started by the launcher or is
+// running on its own
+func isGameRunning(gameVersion *version.GameVersion) bool {
+	if launcher.IsVersionGameRunning(gameVersion.ID) || launcher.IsGameRunning() {
+		return true
+	}
+	if gameVersion.ExecutableName == "" {
+		return false
+	}
+	// pgrep exits with 1 when nothing matches
+	return exec.Command("pgrep", "-f", gameVersion.ExecutableName).Run() == nil
+}
+
+func sendNotification(title, content string) {
+	if app := fyne.CurrentApp(); app != nil {
+		app.SendNotification(fyne.NewNotification(title, content))
+	}
+}
+
+// showUpdateSettingsPopup edits the background update check preferences
+func (am *AddonManager) showUpdateSettingsPopup() {
+	prefs, err := utils.LoadPrefs()
+	if err != nil {
+		dialog.ShowError(err, am.window)
+		return
+	}
+
+	onStartCheck := widget.NewCheck("Check for updates when TurtleSilicon starts", nil)
+	onStartCheck.SetChecked(prefs.AddonCheckOnStart)
+
+	intervalOptions := []string{"Never", "Every hour", "Every 6 hours", "Daily"}
+	intervalHours := []int{0, 1, 6, 24}
+	intervalSelect := widget.NewSelect(intervalOptions, nil)
+	intervalSelect.SetSelectedIndex(0)
+	for i, hours := range intervalHours {
+		if hours == prefs.AddonCheckIntervalHours {
+			intervalSelect.SetSelectedIndex(i)
+		}
+	}
+
+	autoUpdateCheck := widget.NewCheck("Install updates automatically while the game isn't running", nil)
+	autoUpdateCheck.SetChecked(prefs.AutoUpdateAddons)
+
+	noteLabel := widget.NewLabel("Automatic updates skip pinned addons and addons with local changes.")
+	noteLabel.Wrapping = fyne.TextWrapWord
+	noteLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	content := container.NewVBox(
+		onStartCheck,
+		container.NewHBox(widget.NewLabel("Check in the background:"), intervalSelect),
+		autoUpdateCheck,
+		noteLabel,
+	)
+
+	settingsDialog := dialog.NewCustomConfirm("Update Settings", "Save", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		prefs, err := utils.LoadPrefs()
+		if err != nil {
+			dialog.ShowError(err, am.window)
+			return
+		}
+		prefs.AddonCheckOnStart = onStartCheck.Checked
+		prefs.AddonCheckIntervalHours = intervalHours[intervalSelect.SelectedIndex()]
+		prefs.AutoUpdateAddons = autoUpdateCheck.Checked
+		if err := utils.SavePrefs(prefs); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save settings: %v", err), am.window)
+		}
+	}, am.window)
+	settingsDialog.Resize(fyne.NewSize(500, 250))
+	settingsDialog.Show()
+}
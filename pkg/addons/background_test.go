@@ -0,0 +1,115 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"turtlesilicon/pkg/version"
+)
+
+func TestAutoUpdateCandidates(t *testing.T) {
+	addons := []Addon{
+		{Name: "Ready", NeedsUpdate: true, HasGitRepo: true},
+		{Name: "UpToDate", HasGitRepo: true},
+		{Name: "Zip", NeedsUpdate: true, SourceType: SourceTypeZip},
+		{Name: "Pinned", NeedsUpdate: true, HasGitRepo: true, PinnedRef: "v1.0"},
+		{Name: "Edited", NeedsUpdate: true, HasGitRepo: true, LocalChanges: 2},
+		{Name: "Diverged", NeedsUpdate: true, HasGitRepo: true, Diverged: true},
+	}
+	var names []string
+	for _, addon := range autoUpdateCandidates(addons) {
+		names = append(names, addon.Name)
+	}
+	if want := []string{"Ready"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestUpdateCacheRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	gameVersion := &version.GameVersion{ID: "turtlesilicon"}
+	am := &AddonManager{gameVersion: gameVersion, addons: []Addon{
+		{Name: "pfQuest", NeedsUpdate: true},
+		{Name: "pfUI", NeedsUpdate: true},
+		{Name: "Atlas"},
+	}}
+
+	am.recordUpdateCheck()
+	if got := CachedUpdateCount(gameVersion); got != 2 {
+		t.Fatalf("CachedUpdateCount() = %d, want 2", got)
+	}
+	entry := loadUpdateCache().Versions["turtlesilicon"]
+	if entry == nil || time.Since(entry.CheckedAt) > time.Minute {
+		t.Fatalf("unexpected cache entry %+v", entry)
+	}
+	if CachedUpdateCount(&version.GameVersion{ID: "epochsilicon"}) != 0 {
+		t.Error("other versions should have no cached updates")
+	}
+
+	am.forgetUpdates([]TaskResult{{Name: "pfQuest"}, {Name: "pfUI", Err: errors.New("failed")}})
+	if got := loadUpdateCache().Versions["turtlesilicon"].Updatable; !reflect.DeepEqual(got, []string{"pfUI"}) {
+		t.Errorf("after forgetUpdates: got %v, want [pfUI]", got)
+	}
+}
+
+func TestLockAddonPath(t *testing.T) {
+	unlock := lockAddonPath("/AddOns/pfQuest")
+	acquired := make(chan struct{})
+	go func() {
+		defer lockAddonPath("/AddOns/pfQuest/")()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second lock acquired while the first was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second lock not acquired after unlock")
+	}
+
+	// Other folders aren't blocked
+	lockAddonPath("/AddOns/pfUI")()
+}
+
+func TestRolledBackAddonIsNotAutoUpdated(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	ctx := context.Background()
+	origin, clone := newClonedRepo(t)
+	am := &AddonManager{gitBackend: goGit{}}
+
+	before := am.getLocalCommit(clone)
+	commitFile(t, origin, "Addon.lua", "broken\n")
+	if err := am.git().Pull(ctx, clone); err != nil {
+		t.Fatal(err)
+	}
+	addon := Addon{Name: "Addon", Path: clone, HasGitRepo: true, PreviousCommit: before}
+
+	if err := am.RollbackAddon(&addon); err != nil {
+		t.Fatal(err)
+	}
+	if addon.LocalCommit != before || am.getCurrentBranch(clone) == "" {
+		t.Errorf("rolled back to %s on branch %q, want %s on the branch", addon.LocalCommit, am.getCurrentBranch(clone), before)
+	}
+	if addon.PinnedKind != PinCommit || addon.PinnedRef != before {
+		t.Errorf("pinned to %s %s after rolling back", addon.PinnedKind, addon.PinnedRef)
+	}
+
+	// A later scan resolves the pin rather than the branch upstream
+	addon.RemoteCommit = am.getRemoteCommit(ctx, &addon)
+	addon.NeedsUpdate = addon.RemoteCommit != addon.LocalCommit
+	if candidates := autoUpdateCandidates([]Addon{addon}); len(candidates) != 0 {
+		t.Errorf("rolled back addon is an auto-update candidate")
+	}
+}
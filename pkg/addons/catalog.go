@@ -0,0 +1,337 @@
+package addons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/download"
+	"turtlesilicon/pkg/utils"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Catalog is a list of installable addons, shared as a JSON file:
+//
+//	{"name": "Turtle addons", "addons": [{"name": "pfQuest", "description": "...",
+//	  "url": "https://github.com/shagu/pfQuest", "interfaces": ["11200"], "tags": ["quests"]}]}
+type Catalog struct {
+	Name   string         `json:"name"`
+	Addons []CatalogEntry `json:"addons"`
+}
+
+// CatalogEntry is one addon in a catalog. URL is a git repository or zip archive. An empty
+// Interfaces list means the addon works on every client.
+type CatalogEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Interfaces  []string `json:"interfaces"`
+	Tags        []string `json:"tags"`
+}
+
+// Supports reports whether the addon declares support for the given Interface number
+func (e CatalogEntry) Supports(iface string) bool {
+	if len(e.Interfaces) == 0 || iface == "" {
+		return true
+	}
+	for _, supported := range e.Interfaces {
+		if strings.TrimSpace(supported) == iface {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether every word of the query appears in the name, description or tags
+func (e CatalogEntry) matches(words []string) bool {
+	text := strings.ToLower(e.Name + " " + e.Description + " " + strings.Join(e.Tags, " "))
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadCatalog reads a catalog from an http(s) URL or a local file
+func LoadCatalog(ctx context.Context, source string) (*Catalog, error) {
+	var data []byte
+	if parsedURL, err := url.Parse(source); err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid catalog URL %s: %v", source, err)
+		}
+		resp, err := download.HTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download catalog: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("server returned status %d for %s", resp.StatusCode, source)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to download catalog: %v", err)
+		}
+	} else {
+		if data, err = os.ReadFile(source); err != nil {
+			return nil, fmt.Errorf("failed to read catalog: %v", err)
+		}
+	}
+	return parseCatalog(data)
+}
+
+// parseCatalog decodes and validates a catalog, skipping entries without a name or URL
+func parseCatalog(data []byte) (*Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %v", err)
+	}
+
+	valid := catalog.Addons[:0]
+	for _, entry := range catalog.Addons {
+		if strings.TrimSpace(entry.Name) == "" || strings.TrimSpace(entry.URL) == "" {
+			debug.Printf("Skipping catalog entry without name or URL: %+v", entry)
+			continue
+		}
+		valid = append(valid, entry)
+	}
+	if len(valid) == 0 {
+		return nil, fmt.Errorf("the catalog doesn't list any addons")
+	}
+	catalog.Addons = valid
+
+	sort.SliceStable(catalog.Addons, func(i, j int) bool {
+		return strings.ToLower(catalog.Addons[i].Name) < strings.ToLower(catalog.Addons[j].Name)
+	})
+	return &catalog, nil
+}
+
+// Search returns the entries matching the query that support the given Interface number. An
+// empty iface disables the version filter.
+func (c *Catalog) Search(query, iface string) []CatalogEntry {
+	words := strings.Fields(strings.ToLower(query))
+
+	var results []CatalogEntry
+	for _, entry := range c.Addons {
+		if entry.Supports(iface) && entry.matches(words) {
+			results = append(results, entry)
+		}
+	}
+	return results
+}
+
+// isCatalogEntryInstalled reports whether an installed addon came from the entry's URL or
+// has its name
+func (am *AddonManager) isCatalogEntryInstalled(entry CatalogEntry) bool {
+	for _, addon := range am.addons {
+		if (addon.SourceURL != "" && sameRepoURL(addon.SourceURL, entry.URL)) || strings.EqualFold(addon.Name, entry.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// showCatalogPopup loads a catalog and lets the user search it and install addons from it
+func (am *AddonManager) showCatalogPopup() {
+	var catalog *Catalog
+	var results []CatalogEntry
+	var stateMutex sync.Mutex
+	installing := make(map[string]bool)
+	installedURLs := make(map[string]bool)
+
+	sourceEntry := widget.NewEntry()
+	sourceEntry.SetPlaceHolder("https://example.com/addons.json or a local file")
+	if prefs, err := utils.LoadPrefs(); err == nil {
+		sourceEntry.SetText(prefs.AddonCatalogSource)
+	}
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Search by name, description or tag")
+
+	expected := am.expectedInterface()
+	allVersionsCheck := widget.NewCheck("Show addons for other clients", nil)
+	if expected == "" {
+		allVersionsCheck.SetChecked(true)
+		allVersionsCheck.Disable()
+	}
+
+	statusLabel := widget.NewLabel("Load a catalog to browse addons.")
+
+	var resultList *widget.List
+	resultList = widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject {
+			nameLabel := widget.NewLabel("")
+			nameLabel.TextStyle = fyne.TextStyle{Bold: true}
+			descriptionLabel := widget.NewLabel("")
+			descriptionLabel.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, nil, widget.NewButton("Install", nil), container.NewVBox(nameLabel, descriptionLabel))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := results[id]
+			row := item.(*fyne.Container)
+			labels := row.Objects[0].(*fyne.Container)
+			installButton := row.Objects[1].(*widget.Button)
+
+			name := entry.Name
+			if len(entry.Tags) > 0 {
+				name += "  [" + strings.Join(entry.Tags, ", ") + "]"
+			}
+			labels.Objects[0].(*widget.Label).SetText(name)
+			labels.Objects[1].(*widget.Label).SetText(entry.Description)
+
+			stateMutex.Lock()
+			busy := installing[entry.URL]
+			stateMutex.Unlock()
+
+			switch {
+			case busy:
+				installButton.SetText("Installing...")
+				installButton.Disable()
+			case installedURLs[entry.URL] || am.isCatalogEntryInstalled(entry):
+				installButton.SetText("Installed")
+				installButton.Disable()
+			default:
+				installButton.SetText("Install")
+				installButton.Enable()
+			}
+
+			installButton.OnTapped = func() {
+				stateMutex.Lock()
+				installing[entry.URL] = true
+				stateMutex.Unlock()
+				resultList.RefreshItem(id)
+
+				go func() {
+					err := am.installSource(context.Background(), entry.URL)
+
+					stateMutex.Lock()
+					delete(installing, entry.URL)
+					stateMutex.Unlock()
+
+					fyne.Do(func() {
+						if err != nil {
+							dialog.ShowError(fmt.Errorf("failed to install %s: %v", entry.Name, err), am.window)
+						} else {
+							installedURLs[entry.URL] = true
+						}
+						resultList.Refresh()
+					})
+				}()
+			}
+		},
+	)
+
+	updateResults := func() {
+		if catalog == nil {
+			return
+		}
+		filter := expected
+		if allVersionsCheck.Checked {
+			filter = ""
+		}
+		results = catalog.Search(searchEntry.Text, filter)
+		resultList.Refresh()
+
+		status := fmt.Sprintf("%s: %d of %d addons", catalog.Name, len(results), len(catalog.Addons))
+		if filter != "" {
+			status += fmt.Sprintf(" (Interface %s)", filter)
+		}
+		statusLabel.SetText(status)
+	}
+	searchEntry.OnChanged = func(string) { updateResults() }
+	allVersionsCheck.OnChanged = func(bool) { updateResults() }
+
+	loadCatalog := func(source string) {
+		source = strings.TrimSpace(source)
+		if source == "" {
+			return
+		}
+		statusLabel.SetText("Loading catalog...")
+		go func() {
+			loaded, err := LoadCatalog(context.Background(), source)
+			fyne.Do(func() {
+				if err != nil {
+					statusLabel.SetText("Failed to load catalog.")
+					dialog.ShowError(err, am.window)
+					return
+				}
+				catalog = loaded
+				if catalog.Name == "" {
+					catalog.Name = "Catalog"
+				}
+				updateResults()
+
+				if prefs, err := utils.LoadPrefs(); err == nil && prefs.AddonCatalogSource != source {
+					prefs.AddonCatalogSource = source
+					if err := utils.SavePrefs(prefs); err != nil {
+						debug.Printf("Failed to save catalog source: %v", err)
+					}
+				}
+			})
+		}()
+	}
+
+	loadButton := widget.NewButton("Load", func() {
+		loadCatalog(sourceEntry.Text)
+	})
+	loadButton.Importance = widget.HighImportance
+
+	fileButton := widget.NewButton("File...", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, am.window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+			sourceEntry.SetText(path)
+			loadCatalog(path)
+		}, am.window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fileDialog.Resize(am.window.Canvas().Size())
+		fileDialog.Show()
+	})
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, container.NewHBox(fileButton, loadButton), sourceEntry),
+			container.NewBorder(nil, nil, nil, allVersionsCheck, searchEntry),
+			statusLabel,
+			widget.NewSeparator(),
+		),
+		nil,
+		nil,
+		nil,
+		resultList,
+	)
+
+	catalogDialog := dialog.NewCustom("Addon Catalog", "Close", content, am.window)
+	catalogDialog.SetOnClosed(func() {
+		// Rescan once at the end so the new addons show up in the manager
+		if len(installedURLs) > 0 {
+			am.refreshAddonManager()
+		}
+	})
+	windowSize := am.window.Content().Size()
+	catalogDialog.Resize(fyne.NewSize(windowSize.Width*3/4, windowSize.Height*3/4))
+	catalogDialog.Show()
+
+	loadCatalog(sourceEntry.Text)
+}
@@ -0,0 +1,56 @@
+package addons
+
+import "testing"
+
+func TestCatalogSearch(t *testing.T) {
+	data := []byte(`{"name": "Test", "addons": [
+		{"name": "pfQuest", "description": "Quest helper", "url": "https://github.com/shagu/pfQuest", "interfaces": ["11200"], "tags": ["quests"]},
+		{"name": "Questie", "description": "Quest helper", "url": "https://github.com/Questie/Questie", "interfaces": ["30300"]},
+		{"name": "Bagnon", "description": "Bags", "url": "https://example.com/Bagnon.zip"},
+		{"name": "", "url": "https://example.com/broken.zip"}
+	]}`)
+
+	catalog, err := parseCatalog(data)
+	if err != nil {
+		t.Fatalf("parseCatalog() error = %v", err)
+	}
+	if len(catalog.Addons) != 3 || catalog.Addons[0].Name != "Bagnon" {
+		t.Fatalf("parseCatalog() = %+v, want 3 addons sorted by name", catalog.Addons)
+	}
+
+	names := func(entries []CatalogEntry) []string {
+		var result []string
+		for _, entry := range entries {
+			result = append(result, entry.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		query, iface string
+		want         []string
+	}{
+		{"quest", "11200", []string{"pfQuest"}},
+		{"quest", "", []string{"pfQuest", "Questie"}},
+		{"", "11200", []string{"Bagnon", "pfQuest"}},
+		{"quest helper", "30300", []string{"Questie"}},
+		{"QUESTS", "", []string{"pfQuest"}},
+	}
+	for _, tt := range tests {
+		got := names(catalog.Search(tt.query, tt.iface))
+		if len(got) != len(tt.want) {
+			t.Errorf("Search(%q, %q) = %v, want %v", tt.query, tt.iface, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Search(%q, %q) = %v, want %v", tt.query, tt.iface, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if _, err := parseCatalog([]byte(`{"addons": []}`)); err == nil {
+		t.Errorf("parseCatalog() of an empty catalog should fail")
+	}
+}
@@ -0,0 +1,71 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommitInfo summarizes one commit in an addon's pending changelog
+type CommitInfo struct {
+	Hash         string
+	Author       string
+	Date         time.Time
+	Subject      string
+	FilesChanged int
+}
+
+// String formats the commit as a single changelog line
+func (c CommitInfo) String() string {
+	line := fmt.Sprintf("%s  %s  %s: %s", shortCommit(c.Hash), c.Date.Format("2006-01-02"), c.Author, c.Subject)
+	if c.FilesChanged > 0 {
+		line += fmt.Sprintf(" (%d files)", c.FilesChanged)
+	}
+	return line
+}
+
+const (
+	logRecordSeparator = "\x1e"
+	logFieldSeparator  = "\x1f"
+)
+
+// Changelog returns the commits between the addon's local and remote commit, newest first
+func (am *AddonManager) Changelog(ctx context.Context, addon *Addon) ([]CommitInfo, error) {
+	if addon.LocalCommit == "" || addon.RemoteCommit == "" || addon.LocalCommit == addon.RemoteCommit {
+		return nil, nil
+	}
+
+	return am.git().Log(ctx, addon.Path, addon.LocalCommit, addon.RemoteCommit)
+}
+
+// parseGitLog parses the output of git log using the format from Changelog
+func parseGitLog(output string) []CommitInfo {
+	var commits []CommitInfo
+	for _, record := range strings.Split(output, logRecordSeparator) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		header, stats, _ := strings.Cut(record, "\n")
+		fields := strings.SplitN(header, logFieldSeparator, 4)
+		if len(fields) != 4 {
+			continue
+		}
+
+		commit := CommitInfo{Hash: fields[0], Author: fields[1], Subject: fields[3]}
+		if timestamp, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			commit.Date = time.Unix(timestamp, 0)
+		}
+
+		// --shortstat adds " 3 files changed, 10 insertions(+), 2 deletions(-)"
+		if count, rest, found := strings.Cut(strings.TrimSpace(stats), " "); found && strings.HasPrefix(rest, "file") {
+			commit.FilesChanged, _ = strconv.Atoi(count)
+		}
+
+		commits = append(commits, commit)
+	}
+	return commits
+}
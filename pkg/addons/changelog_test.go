@@ -0,0 +1,21 @@
+package addons
+
+import "testing"
+
+func TestParseGitLog(t *testing.T) {
+	output := "\x1e24034ba8d9270dff7c9df09bfee04db11a443f36\x1fJane Doe\x1f1792359410\x1fAdd raid frames\n\n 3 files changed, 10 insertions(+), 2 deletions(-)\n" +
+		"\x1e4fb4f00e14fa3164a14c45538fd6b03c6893e62e\x1fJohn\x1f1792358960\x1fMerge branch 'dev'\n"
+
+	commits := parseGitLog(output)
+	if len(commits) != 2 {
+		t.Fatalf("parseGitLog() returned %d commits, want 2", len(commits))
+	}
+
+	first := commits[0]
+	if first.Author != "Jane Doe" || first.Subject != "Add raid frames" || first.FilesChanged != 3 || first.Date.Unix() != 1792359410 {
+		t.Errorf("first commit = %+v", first)
+	}
+	if commits[1].FilesChanged != 0 || commits[1].Subject != "Merge branch 'dev'" {
+		t.Errorf("second commit = %+v", commits[1])
+	}
+}
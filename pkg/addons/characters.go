@@ -0,0 +1,287 @@
+package addons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Character is a character folder below WTF/Account, created by the client on first login
+type Character struct {
+	Account string
+	Realm   string
+	Name    string
+	Path    string
+}
+
+// Label returns the character as Account/Realm/Character
+func (c Character) Label() string {
+	return c.Account + "/" + c.Realm + "/" + c.Name
+}
+
+// addOnsTxtPath returns the character's AddOns.txt, which may not exist yet
+func (c Character) addOnsTxtPath() string {
+	return filepath.Join(c.Path, "AddOns.txt")
+}
+
+// FindCharacters lists the characters of every account and realm below the game's WTF folder
+func FindCharacters(gamePath string) []Character {
+	accountPath := wtfAccountPath(gamePath)
+	if accountPath == "" {
+		return nil
+	}
+
+	var characters []Character
+	for _, account := range subdirectories(accountPath) {
+		for _, realm := range subdirectories(filepath.Join(accountPath, account)) {
+			// Account-wide SavedVariables live next to the realm folders
+			if strings.EqualFold(realm, "SavedVariables") {
+				continue
+			}
+			for _, name := range subdirectories(filepath.Join(accountPath, account, realm)) {
+				characters = append(characters, Character{
+					Account: account,
+					Realm:   realm,
+					Name:    name,
+					Path:    filepath.Join(accountPath, account, realm, name),
+				})
+			}
+		}
+	}
+	return characters
+}
+
+// subdirectories returns the names of the directories in dir, sorted
+func subdirectories(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CharacterAddonStates returns the character's AddOns.txt as a map of lowercase addon name to
+// enabled state. Characters without their own file use the account's.
+func CharacterAddonStates(character Character) map[string]bool {
+	for _, path := range []string{character.addOnsTxtPath(), filepath.Join(filepath.Dir(filepath.Dir(character.Path)), "AddOns.txt")} {
+		enabled, err := readAddOnsTxt(path)
+		if err == nil {
+			return enabled
+		}
+		if !os.IsNotExist(err) {
+			debug.Printf("Failed to read %s: %v", path, err)
+		}
+	}
+	return make(map[string]bool)
+}
+
+// SetCharacterAddonStates writes the given enabled states into the character's AddOns.txt,
+// keeping entries for addons not in states as they are
+func SetCharacterAddonStates(character Character, states map[string]bool) error {
+	path := character.addOnsTxtPath()
+
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if err := os.WriteFile(path, []byte(applyAddOnsTxt(string(content), states)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	debug.Printf("Updated addon states for %s", character.Label())
+	return nil
+}
+
+// applyAddOnsTxt rewrites AddOns.txt content with the given enabled states. Existing lines
+// keep their order and casing, addons that aren't listed yet are appended.
+func applyAddOnsTxt(content string, states map[string]bool) string {
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+
+	pending := make(map[string]string)
+	for name := range states {
+		pending[strings.ToLower(name)] = name
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, _, found := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if original, ok := pending[strings.ToLower(name)]; found && ok {
+			line = addOnsTxtLine(name, states[original])
+			delete(pending, strings.ToLower(name))
+		}
+		lines = append(lines, line)
+	}
+
+	var added []string
+	for _, name := range pending {
+		added = append(added, name)
+	}
+	sort.Slice(added, func(i, j int) bool {
+		return strings.ToLower(added[i]) < strings.ToLower(added[j])
+	})
+	for _, name := range added {
+		lines = append(lines, addOnsTxtLine(name, states[name]))
+	}
+
+	return strings.Join(lines, newline) + newline
+}
+
+func addOnsTxtLine(name string, enabled bool) string {
+	if enabled {
+		return name + ": enabled"
+	}
+	return name + ": disabled"
+}
+
+// showCharactersPopup shows which addons each character has enabled and lets the user change
+// them, for one character or several at once
+func (am *AddonManager) showCharactersPopup() {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		dialog.ShowError(err, am.window)
+		return
+	}
+
+	characters := FindCharacters(gamePath)
+	if len(characters) == 0 {
+		dialog.ShowInformation("No Characters", "No characters were found in the WTF folder. Log in with a character once so the game creates its settings.", am.window)
+		return
+	}
+	if len(am.addons) == 0 {
+		dialog.ShowInformation("No Addons", "There are no installed addons to enable or disable.", am.window)
+		return
+	}
+
+	var labels []string
+	for _, character := range characters {
+		labels = append(labels, character.Label())
+	}
+
+	addonNames := make([]string, len(am.addons))
+	for i, addon := range am.addons {
+		addonNames[i] = addon.Name
+	}
+
+	checks := make([]*widget.Check, len(am.addons))
+	checksContainer := container.NewVBox()
+	for i, addon := range am.addons {
+		checks[i] = widget.NewCheck(addon.DisplayName(), nil)
+		checksContainer.Add(checks[i])
+	}
+
+	currentStates := func() map[string]bool {
+		states := make(map[string]bool)
+		for i, check := range checks {
+			states[addonNames[i]] = check.Checked
+		}
+		return states
+	}
+
+	characterSelect := widget.NewSelect(labels, nil)
+	characterSelect.OnChanged = func(string) {
+		enabled := CharacterAddonStates(characters[characterSelect.SelectedIndex()])
+		for i, check := range checks {
+			check.SetChecked(isAddonEnabled(enabled, addonNames[i]))
+		}
+	}
+	characterSelect.SetSelectedIndex(0)
+
+	applyStates := func(targets []Character) {
+		states := currentStates()
+		var failures []string
+		for _, target := range targets {
+			if err := SetCharacterAddonStates(target, states); err != nil {
+				failures = append(failures, err.Error())
+			}
+		}
+
+		// Disabled dependencies are reported per character, so re-check them
+		am.dependencyIssues = am.CheckDependencies()
+		am.refreshAddonList()
+
+		if len(failures) > 0 {
+			dialog.ShowError(fmt.Errorf("%s", strings.Join(failures, "\n")), am.window)
+			return
+		}
+		dialog.ShowInformation("Saved", fmt.Sprintf("Updated the addon list of %d characters.", len(targets)), am.window)
+	}
+
+	enableAllButton := widget.NewButton("Enable All", func() {
+		for _, check := range checks {
+			check.SetChecked(true)
+		}
+	})
+	disableAllButton := widget.NewButton("Disable All", func() {
+		for _, check := range checks {
+			check.SetChecked(false)
+		}
+	})
+
+	saveButton := widget.NewButton("Save", func() {
+		applyStates([]Character{characters[characterSelect.SelectedIndex()]})
+	})
+	saveButton.Importance = widget.HighImportance
+
+	applyToButton := widget.NewButton("Apply to Characters...", func() {
+		targetGroup := widget.NewCheckGroup(labels, nil)
+		targetGroup.SetSelected([]string{characterSelect.Selected})
+
+		targetDialog := dialog.NewCustomConfirm("Apply to Characters", "Apply", "Cancel", container.NewScroll(targetGroup), func(confirmed bool) {
+			if !confirmed || len(targetGroup.Selected) == 0 {
+				return
+			}
+			var targets []Character
+			for _, character := range characters {
+				for _, selected := range targetGroup.Selected {
+					if character.Label() == selected {
+						targets = append(targets, character)
+					}
+				}
+			}
+			applyStates(targets)
+		}, am.window)
+		targetDialog.Resize(fyne.NewSize(400, 400))
+		targetDialog.Show()
+	})
+	applyToButton.Importance = widget.MediumImportance
+
+	noteLabel := widget.NewLabel("The game rewrites these files when you log out, so close it before making changes.")
+	noteLabel.Wrapping = fyne.TextWrapWord
+	noteLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	content := container.NewBorder(
+		container.NewVBox(characterSelect, noteLabel, container.NewHBox(enableAllButton, disableAllButton), widget.NewSeparator()),
+		container.NewHBox(saveButton, applyToButton),
+		nil,
+		nil,
+		container.NewScroll(checksContainer),
+	)
+
+	charactersDialog := dialog.NewCustom("Addons per Character", "Close", content, am.window)
+	windowSize := am.window.Content().Size()
+	charactersDialog.Resize(fyne.NewSize(windowSize.Width*2/3, windowSize.Height*3/4))
+	charactersDialog.Show()
+}
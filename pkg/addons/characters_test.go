@@ -0,0 +1,122 @@
+package addons
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyAddOnsTxt(t *testing.T) {
+	content := "pfUI: enabled\r\npfQuest: enabled\r\nAtlas: disabled\r\n"
+	states := map[string]bool{"pfquest": false, "Atlas": true, "Bagnon": true}
+
+	want := "pfUI: enabled\r\npfQuest: disabled\r\nAtlas: enabled\r\nBagnon: enabled\r\n"
+	if got := applyAddOnsTxt(content, states); got != want {
+		t.Errorf("applyAddOnsTxt() = %q, want %q", got, want)
+	}
+
+	if got := applyAddOnsTxt("", map[string]bool{"pfUI": false}); got != "pfUI: disabled\n" {
+		t.Errorf("applyAddOnsTxt() on empty file = %q", got)
+	}
+}
+
+func TestReadAddOnsTxt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]bool
+	}{
+		{"empty", "", map[string]bool{}},
+		{"states", "pfUI: enabled\npfQuest: disabled\n", map[string]bool{"pfui": true, "pfquest": false}},
+		{"crlf and spacing", "pfUI :  Enabled\r\n  Atlas:DISABLED\r\n", map[string]bool{"pfui": true, "atlas": false}},
+		{"malformed lines", "pfUI\n\n# comment\nAtlas: enabled\n", map[string]bool{"atlas": true}},
+		{"unknown state", "pfUI: maybe\n", map[string]bool{"pfui": false}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "AddOns.txt")
+		os.WriteFile(path, []byte(tt.content), 0644)
+		got, err := readAddOnsTxt(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := readAddOnsTxt(filepath.Join(t.TempDir(), "AddOns.txt")); !os.IsNotExist(err) {
+		t.Errorf("missing file: got %v, want a not-exist error", err)
+	}
+}
+
+func TestIsAddonEnabled(t *testing.T) {
+	enabled := map[string]bool{"pfui": true, "atlas": false}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"pfUI", true},
+		{"Atlas", false},
+		{"ATLAS", false},
+		{"NotListed", true},
+	}
+	for _, tt := range tests {
+		if got := isAddonEnabled(enabled, tt.name); got != tt.want {
+			t.Errorf("isAddonEnabled(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindCharacters(t *testing.T) {
+	gamePath := t.TempDir()
+	if got := FindCharacters(gamePath); got != nil {
+		t.Errorf("without WTF folder: got %v", got)
+	}
+
+	accountPath := filepath.Join(gamePath, "WTF", "Account")
+	for _, dir := range []string{
+		"MYACCOUNT/Nordanaar/Shagu",
+		"MYACCOUNT/Nordanaar/Alt",
+		"MYACCOUNT/Tel'Abim/Healer",
+		"MYACCOUNT/SavedVariables",
+		"MYACCOUNT/.hidden/Ghost",
+		"OTHER/Nordanaar/Tank",
+	} {
+		os.MkdirAll(filepath.Join(accountPath, filepath.FromSlash(dir)), 0755)
+	}
+	os.WriteFile(filepath.Join(accountPath, "MYACCOUNT", "Nordanaar", "realm-file.txt"), nil, 0644)
+
+	var labels []string
+	for _, character := range FindCharacters(gamePath) {
+		labels = append(labels, character.Label())
+		if want := filepath.Join(accountPath, character.Account, character.Realm, character.Name); character.Path != want {
+			t.Errorf("%s: path %s, want %s", character.Label(), character.Path, want)
+		}
+	}
+	want := []string{"MYACCOUNT/Nordanaar/Alt", "MYACCOUNT/Nordanaar/Shagu", "MYACCOUNT/Tel'Abim/Healer", "OTHER/Nordanaar/Tank"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("got %v, want %v", labels, want)
+	}
+}
+
+func TestCharacterAddonStates(t *testing.T) {
+	accountPath := t.TempDir()
+	characterPath := filepath.Join(accountPath, "Nordanaar", "Shagu")
+	os.MkdirAll(characterPath, 0755)
+	character := Character{Account: "MYACCOUNT", Realm: "Nordanaar", Name: "Shagu", Path: characterPath}
+
+	if got := CharacterAddonStates(character); len(got) != 0 {
+		t.Errorf("without any AddOns.txt: got %v", got)
+	}
+
+	os.WriteFile(filepath.Join(accountPath, "AddOns.txt"), []byte("pfUI: disabled\n"), 0644)
+	if got := CharacterAddonStates(character); !reflect.DeepEqual(got, map[string]bool{"pfui": false}) {
+		t.Errorf("account fallback: got %v", got)
+	}
+
+	os.WriteFile(filepath.Join(characterPath, "AddOns.txt"), []byte("pfUI: enabled\n"), 0644)
+	if got := CharacterAddonStates(character); !reflect.DeepEqual(got, map[string]bool{"pfui": true}) {
+		t.Errorf("character file: got %v", got)
+	}
+}
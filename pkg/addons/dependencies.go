@@ -0,0 +1,240 @@
+package addons
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+)
+
+const (
+	DependencyMissing  = "missing"
+	DependencyDisabled = "disabled"
+	DependencyCycle    = "cycle"
+)
+
+// DependencyIssue describes a problem with one of an addon's required dependencies
+type DependencyIssue struct {
+	Addon      string
+	Dependency string
+	Kind       string
+	Detail     string
+}
+
+// String returns a human-readable description of the issue
+func (issue DependencyIssue) String() string {
+	switch issue.Kind {
+	case DependencyMissing:
+		return fmt.Sprintf("%s requires %s, which is not installed", issue.Addon, issue.Dependency)
+	case DependencyDisabled:
+		return fmt.Sprintf("%s requires %s, which is disabled for %s", issue.Addon, issue.Dependency, issue.Detail)
+	case DependencyCycle:
+		return fmt.Sprintf("%s is part of a dependency cycle: %s", issue.Addon, issue.Detail)
+	default:
+		return fmt.Sprintf("%s: problem with dependency %s", issue.Addon, issue.Dependency)
+	}
+}
+
+// CheckDependencies builds the dependency graph of the installed addons and reports missing
+// and disabled required dependencies as well as dependency cycles
+func (am *AddonManager) CheckDependencies() []DependencyIssue {
+	// Enabled state is stored per account or character, so check each AddOns.txt separately
+	enabledSets := make(map[string]map[string]bool)
+	if gamePath, err := am.gamePath(); err == nil {
+		for _, addOnsTxt := range findAddOnsTxtFiles(gamePath) {
+			enabled, err := readAddOnsTxt(addOnsTxt)
+			if err != nil {
+				debug.Printf("Failed to read %s: %v", addOnsTxt, err)
+				continue
+			}
+			enabledSets[addOnsTxtLabel(gamePath, addOnsTxt)] = enabled
+		}
+	}
+
+	return checkDependencies(am.addons, enabledSets)
+}
+
+// checkDependencies reports dependency issues of addons, given the parsed AddOns.txt files
+// keyed by their Account/Realm/Character label
+func checkDependencies(addons []Addon, enabledSets map[string]map[string]bool) []DependencyIssue {
+	index := make(map[string]*Addon)
+	for i := range addons {
+		index[strings.ToLower(addons[i].Name)] = &addons[i]
+	}
+
+	var issues []DependencyIssue
+	for _, addon := range addons {
+		for _, dep := range addon.Dependencies {
+			// Blizzard's load-on-demand addons come with the game archives
+			if isBlizzardAddon(dep) {
+				continue
+			}
+			if _, installed := index[strings.ToLower(dep)]; !installed {
+				issues = append(issues, DependencyIssue{Addon: addon.Name, Dependency: dep, Kind: DependencyMissing})
+				continue
+			}
+
+			var disabledFor []string
+			for label, enabled := range enabledSets {
+				if isAddonEnabled(enabled, addon.Name) && !isAddonEnabled(enabled, dep) {
+					disabledFor = append(disabledFor, label)
+				}
+			}
+			if len(disabledFor) > 0 {
+				sort.Strings(disabledFor)
+				issues = append(issues, DependencyIssue{Addon: addon.Name, Dependency: dep, Kind: DependencyDisabled, Detail: strings.Join(disabledFor, ", ")})
+			}
+		}
+	}
+
+	issues = append(issues, findDependencyCycles(addons, index)...)
+	return issues
+}
+
+// findDependencyCycles walks the required dependencies depth-first and reports each cycle once
+func findDependencyCycles(addons []Addon, index map[string]*Addon) []DependencyIssue {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	reported := make(map[string]bool)
+	var issues []DependencyIssue
+	var stack []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, dep := range index[name].Dependencies {
+			depKey := strings.ToLower(dep)
+			if _, installed := index[depKey]; !installed {
+				continue
+			}
+
+			switch state[depKey] {
+			case unvisited:
+				visit(depKey)
+			case visiting:
+				// Everything on the stack from dep onwards forms the cycle
+				start := 0
+				for i, entry := range stack {
+					if entry == depKey {
+						start = i
+						break
+					}
+				}
+				members := append([]string{}, stack[start:]...)
+
+				sortedMembers := append([]string{}, members...)
+				sort.Strings(sortedMembers)
+				key := strings.Join(sortedMembers, ",")
+				if reported[key] {
+					continue
+				}
+				reported[key] = true
+
+				var names []string
+				for _, member := range members {
+					names = append(names, index[member].Name)
+				}
+				names = append(names, index[depKey].Name)
+				cyclePath := strings.Join(names, " → ")
+				for _, member := range members {
+					issues = append(issues, DependencyIssue{Addon: index[member].Name, Kind: DependencyCycle, Detail: cyclePath})
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+	}
+
+	for _, addon := range addons {
+		if key := strings.ToLower(addon.Name); state[key] == unvisited {
+			visit(key)
+		}
+	}
+
+	return issues
+}
+
+// Dependents returns the installed addons that require the named addon
+func (am *AddonManager) Dependents(name string) []string {
+	var dependents []string
+	for _, addon := range am.addons {
+		for _, dep := range addon.Dependencies {
+			if strings.EqualFold(dep, name) {
+				dependents = append(dependents, addon.Name)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+// issuesForAddon filters dependency issues down to those reported for one addon
+func issuesForAddon(issues []DependencyIssue, name string) []DependencyIssue {
+	var result []DependencyIssue
+	for _, issue := range issues {
+		if issue.Addon == name {
+			result = append(result, issue)
+		}
+	}
+	return result
+}
+
+// findAddOnsTxtFiles returns the account and character level AddOns.txt files below WTF/Account
+func findAddOnsTxtFiles(gamePath string) []string {
+	accountPath := wtfAccountPath(gamePath)
+	if accountPath == "" {
+		return nil
+	}
+
+	accountFiles, _ := filepath.Glob(filepath.Join(accountPath, "*", "AddOns.txt"))
+	characterFiles, _ := filepath.Glob(filepath.Join(accountPath, "*", "*", "*", "AddOns.txt"))
+	return append(accountFiles, characterFiles...)
+}
+
+// addOnsTxtLabel turns an AddOns.txt path into an Account/Realm/Character label
+func addOnsTxtLabel(gamePath, addOnsTxtPath string) string {
+	rel, err := filepath.Rel(wtfAccountPath(gamePath), filepath.Dir(addOnsTxtPath))
+	if err != nil {
+		return addOnsTxtPath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// readAddOnsTxt parses an AddOns.txt file into a map of lowercase addon name to enabled state
+func readAddOnsTxt(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	enabled := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, state, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		enabled[strings.ToLower(strings.TrimSpace(name))] = strings.EqualFold(strings.TrimSpace(state), "enabled")
+	}
+	return enabled, scanner.Err()
+}
+
+// isAddonEnabled reports whether an addon is enabled in a parsed AddOns.txt. Addons that
+// aren't listed yet are enabled by the client by default.
+func isAddonEnabled(enabled map[string]bool, name string) bool {
+	state, listed := enabled[strings.ToLower(name)]
+	return !listed || state
+}
@@ -0,0 +1,81 @@
+package addons
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	addons := []Addon{
+		{Name: "pfQuest", Dependencies: []string{"pfUI", "Blizzard_AuctionUI"}},
+		{Name: "pfUI"},
+		{Name: "Atlas", Dependencies: []string{"AtlasLoot"}},
+		{Name: "Cartographer", Dependencies: []string{"Ace2"}},
+		{Name: "Ace2"},
+	}
+	enabledSets := map[string]map[string]bool{
+		"ACCOUNT":               {"cartographer": true, "ace2": false},
+		"ACCOUNT/Realm/Shaguar": {"pfquest": true, "pfui": true},
+	}
+
+	issues := checkDependencies(addons, enabledSets)
+	want := []DependencyIssue{
+		{Addon: "Atlas", Dependency: "AtlasLoot", Kind: DependencyMissing},
+		{Addon: "Cartographer", Dependency: "Ace2", Kind: DependencyDisabled, Detail: "ACCOUNT"},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("got %+v, want %+v", issues, want)
+	}
+}
+
+func TestFindDependencyCycles(t *testing.T) {
+	tests := []struct {
+		name   string
+		addons []Addon
+		want   []string
+	}{
+		{"no cycle", []Addon{{Name: "A", Dependencies: []string{"B"}}, {Name: "B"}}, nil},
+		{"self", []Addon{{Name: "A", Dependencies: []string{"a"}}}, []string{"A"}},
+		{"two addons", []Addon{{Name: "A", Dependencies: []string{"B"}}, {Name: "B", Dependencies: []string{"A"}}}, []string{"A", "B"}},
+		{"three addons", []Addon{
+			{Name: "A", Dependencies: []string{"B"}},
+			{Name: "B", Dependencies: []string{"C"}},
+			{Name: "C", Dependencies: []string{"A"}},
+			{Name: "D", Dependencies: []string{"A"}},
+		}, []string{"A", "B", "C"}},
+		{"missing dependency", []Addon{{Name: "A", Dependencies: []string{"Missing"}}}, nil},
+	}
+	for _, tt := range tests {
+		index := make(map[string]*Addon)
+		for i := range tt.addons {
+			index[strings.ToLower(tt.addons[i].Name)] = &tt.addons[i]
+		}
+		var got []string
+		for _, issue := range findDependencyCycles(tt.addons, index) {
+			if issue.Kind != DependencyCycle {
+				t.Errorf("%s: unexpected issue kind %s", tt.name, issue.Kind)
+			}
+			got = append(got, issue.Addon)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDependents(t *testing.T) {
+	am := &AddonManager{addons: []Addon{
+		{Name: "pfQuest", Dependencies: []string{"pfUI"}},
+		{Name: "pfUI-addonskins", Dependencies: []string{"PFUI", "Ace2"}},
+		{Name: "pfUI"},
+	}}
+	if got, want := am.Dependents("pfUI"), []string{"pfQuest", "pfUI-addonskins"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := am.Dependents("pfQuest"); got != nil {
+		t.Errorf("got %v, want none", got)
+	}
+}
@@ -0,0 +1,121 @@
+package addons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/version"
+)
+
+// findAddonsDir returns the addons directory of a game folder. Clients ship with
+// Interface/AddOns, but installs copied from Windows often use other casings, which matters
+// on case-sensitive volumes. If no such directory exists yet, Interface/AddOns is returned.
+func findAddonsDir(gamePath string) string {
+	interfaceDir := findChildFold(gamePath, "Interface")
+	if interfaceDir == "" {
+		return filepath.Join(gamePath, "Interface", "AddOns")
+	}
+	if addonsDir := findChildFold(interfaceDir, "AddOns"); addonsDir != "" {
+		return addonsDir
+	}
+	return filepath.Join(interfaceDir, "AddOns")
+}
+
+// findChildFold returns the path of the directory in dir whose name matches name ignoring
+// case, preferring an exact match, or an empty string if there is none
+func findChildFold(dir, name string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	match := ""
+	for _, entry := range entries {
+		if !strings.EqualFold(entry.Name(), name) || !utils.DirExists(filepath.Join(dir, entry.Name())) {
+			continue
+		}
+		if entry.Name() == name {
+			return filepath.Join(dir, name)
+		}
+		if match == "" {
+			match = filepath.Join(dir, entry.Name())
+		}
+	}
+	return match
+}
+
+// otherGameVersions returns the configured versions, other than the one being managed,
+// that have a game folder set
+func (am *AddonManager) otherGameVersions() []*version.GameVersion {
+	vm, err := version.LoadVersionManager()
+	if err != nil {
+		debug.Printf("Failed to load versions: %v", err)
+		return nil
+	}
+
+	var versions []*version.GameVersion
+	for _, ver := range vm.Versions {
+		if ver.GamePath == "" || (am.gameVersion != nil && ver.ID == am.gameVersion.ID) {
+			continue
+		}
+		versions = append(versions, ver)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].DisplayName < versions[j].DisplayName
+	})
+	return versions
+}
+
+// CopyAddonToVersion copies an addon into another version's addons folder, or symlinks it
+// there if link is set so both versions share one copy. It returns the new addon path.
+func (am *AddonManager) CopyAddonToVersion(addon *Addon, target *version.GameVersion, link bool) (string, error) {
+	if target.GamePath == "" {
+		return "", fmt.Errorf("no game folder set for %s", target.DisplayName)
+	}
+
+	targetAddonsPath := findAddonsDir(target.GamePath)
+	if err := os.MkdirAll(targetAddonsPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create addons directory: %v", err)
+	}
+
+	targetPath := filepath.Join(targetAddonsPath, addon.Name)
+	if _, err := os.Lstat(targetPath); err == nil {
+		return "", fmt.Errorf("%s already has an addon named %s", target.DisplayName, addon.Name)
+	}
+
+	// Link to the real folder so removing this link later doesn't break the new one
+	sourcePath, err := filepath.EvalSymlinks(addon.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve addon folder: %v", err)
+	}
+
+	if link {
+		if err := os.Symlink(sourcePath, targetPath); err != nil {
+			return "", fmt.Errorf("failed to link addon: %v", err)
+		}
+		debug.Printf("Linked %s into %s", addon.Name, target.DisplayName)
+	} else {
+		if err := utils.CopyDir(sourcePath, targetPath); err != nil {
+			os.RemoveAll(targetPath)
+			return "", fmt.Errorf("failed to copy addon: %v", err)
+		}
+		debug.Printf("Copied %s to %s", addon.Name, target.DisplayName)
+	}
+
+	// Carry the source over so the copy can be updated from the same place
+	if source := getAddonSource(addon.Path); source != nil {
+		copied := *source
+		copied.InstalledAt = time.Now()
+		if err := setAddonSource(targetPath, &copied); err != nil {
+			debug.Printf("Warning: failed to record source for %s: %v", targetPath, err)
+		}
+	}
+
+	return targetPath, nil
+}
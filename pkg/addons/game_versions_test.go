@@ -0,0 +1,36 @@
+package addons
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindAddonsDir(t *testing.T) {
+	tests := []struct {
+		name string
+		dirs []string
+		want string
+	}{
+		{"missing", nil, filepath.Join("Interface", "AddOns")},
+		{"standard", []string{filepath.Join("Interface", "AddOns")}, filepath.Join("Interface", "AddOns")},
+		{"lowercase o", []string{filepath.Join("Interface", "Addons")}, filepath.Join("Interface", "Addons")},
+		{"lowercase interface", []string{filepath.Join("interface", "addons")}, filepath.Join("interface", "addons")},
+		{"no addons folder", []string{"Interface"}, filepath.Join("Interface", "AddOns")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gamePath := t.TempDir()
+			for _, dir := range tt.dirs {
+				if err := os.MkdirAll(filepath.Join(gamePath, dir), 0755); err != nil {
+					t.Fatalf("failed to create %s: %v", dir, err)
+				}
+			}
+
+			if got, want := findAddonsDir(gamePath), filepath.Join(gamePath, tt.want); got != want {
+				t.Errorf("findAddonsDir() = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+)
+
+// errGitUnavailable is returned for operations that need the git command line tools when
+// they aren't installed
+var errGitUnavailable = errors.New("this requires the git command line tools, which are not installed")
+
+// gitBackend performs the repository operations the addon manager relies on
+type gitBackend interface {
+	// Name identifies the backend in logs and the UI
+	Name() string
+	// RemoteURL returns the URL of the origin remote, or an empty string
+	RemoteURL(repoPath string) string
+	// HeadCommit returns the commit HEAD points to
+	HeadCommit(repoPath string) (string, error)
+	// RemoteCommit fetches origin and returns the commit an update would move to: the pinned
+	// ref if pinKind is set, otherwise the upstream of the checked out branch
+	RemoteCommit(ctx context.Context, repoPath, pinKind, pinRef string) (string, error)
+	// Pull fast-forwards the checked out branch to its upstream
+	Pull(ctx context.Context, repoPath string) error
+	// Clone clones repoURL into repoPath
+	Clone(ctx context.Context, repoURL, repoPath string) error
+	// LocalChanges returns the number of tracked files with uncommitted changes
+	LocalChanges(ctx context.Context, repoPath string) (int, error)
+	// ResetHard moves HEAD and the working tree to commit, discarding local changes
+	ResetHard(ctx context.Context, repoPath, commit string) error
+	// ResetKeep moves HEAD and the working tree to commit like reset --keep, refusing to
+	// overwrite local changes
+	ResetKeep(ctx context.Context, repoPath, commit string) error
+	// Fetch fetches origin, including its tags
+	Fetch(ctx context.Context, repoPath string) error
+	// CurrentBranch returns the checked out branch, or an empty string for a detached HEAD
+	CurrentBranch(repoPath string) string
+	// DefaultBranch returns the branch origin/HEAD points to, falling back to main or master
+	DefaultBranch(repoPath string) string
+	// Tags returns the repository's tags in no particular order
+	Tags(repoPath string) ([]string, error)
+	// RemoteBranches returns the branches fetched from origin
+	RemoteBranches(repoPath string) ([]string, error)
+	// CheckoutBranch switches to branch, creating it from origin's branch if there's no local one
+	CheckoutBranch(ctx context.Context, repoPath, branch string) error
+	// CheckoutBranchAt points branch at commit, tracking origin's branch of the same name,
+	// and switches to it
+	CheckoutBranchAt(ctx context.Context, repoPath, branch, commit string) error
+	// CheckoutDetached checks out commit with a detached HEAD
+	CheckoutDetached(ctx context.Context, repoPath, commit string) error
+	// AheadBehind counts the commits HEAD has that commit doesn't, and the other way round
+	AheadBehind(ctx context.Context, repoPath, commit string) (ahead, behind int, err error)
+	// Log returns the commits reachable from to but not from, newest first
+	Log(ctx context.Context, repoPath, from, to string) ([]CommitInfo, error)
+	// Stash puts the local changes aside and cleans the working tree
+	Stash(ctx context.Context, repoPath, message string) error
+	// StashPop re-applies the stashed changes. If they conflict, the working tree is left
+	// clean and the changes stay stashed.
+	StashPop(ctx context.Context, repoPath string) error
+}
+
+var (
+	systemGitOnce      sync.Once
+	systemGitAvailable bool
+)
+
+// hasSystemGit reports whether a working git binary is installed. On macOS /usr/bin/git is
+// a stub that pops up the Xcode Command Line Tools installer, so it only counts once the
+// tools are actually installed.
+func hasSystemGit() bool {
+	systemGitOnce.Do(func() {
+		gitPath, err := exec.LookPath("git")
+		if err != nil {
+			return
+		}
+		if runtime.GOOS == "darwin" && gitPath == "/usr/bin/git" {
+			if err := exec.Command("xcode-select", "-p").Run(); err != nil {
+				debug.Printf("git stub found but Command Line Tools are not installed")
+				return
+			}
+		}
+		systemGitAvailable = true
+	})
+	return systemGitAvailable
+}
+
+// newGitBackend returns the system git backend if the user prefers it and it is installed,
+// otherwise the embedded pure-Go backend
+func newGitBackend() gitBackend {
+	if prefs, err := utils.LoadPrefs(); err == nil && prefs.PreferSystemGit && hasSystemGit() {
+		return execGit{}
+	}
+	return goGit{}
+}
+
+// git returns the backend used for repository operations
+func (am *AddonManager) git() gitBackend {
+	if am.gitBackend == nil {
+		return newGitBackend()
+	}
+	return am.gitBackend
+}
+
+// execGit runs the system git binary
+type execGit struct{}
+
+func (execGit) Name() string {
+	return "system git"
+}
+
+func (execGit) RemoteURL(repoPath string) string {
+	return readOriginURL(repoPath)
+}
+
+func (g execGit) HeadCommit(repoPath string) (string, error) {
+	return g.run(context.Background(), repoPath, "rev-parse", "HEAD")
+}
+
+func (g execGit) RemoteCommit(ctx context.Context, repoPath, pinKind, pinRef string) (string, error) {
+	if _, err := g.run(ctx, repoPath, "fetch", "--tags", "origin"); err != nil {
+		debug.Printf("Fetch failed for %s, using the last fetched state: %v", repoPath, err)
+	}
+
+	candidates := []string{"@{u}", "origin/HEAD", "origin/main", "origin/master"}
+	switch pinKind {
+	case PinBranch:
+		candidates = []string{"origin/" + pinRef}
+	case PinTag:
+		candidates = []string{"refs/tags/" + pinRef + "^{commit}"}
+	case PinCommit:
+		candidates = []string{pinRef + "^{commit}"}
+	}
+
+	for _, ref := range candidates {
+		cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", ref)
+		cmd.Dir = repoPath
+		if output, err := cmd.Output(); err == nil {
+			return strings.TrimSpace(string(output)), nil
+		}
+	}
+	return "", fmt.Errorf("none of %s resolved", strings.Join(candidates, ", "))
+}
+
+func (g execGit) Pull(ctx context.Context, repoPath string) error {
+	output, err := g.run(ctx, repoPath, "pull")
+	if err != nil {
+		return fmt.Errorf("git pull failed: %v", err)
+	}
+	debug.Printf("Git pull output: %s", output)
+	return nil
+}
+
+func (g execGit) Clone(ctx context.Context, repoURL, repoPath string) error {
+	output, err := g.run(ctx, filepath.Dir(repoPath), "clone", "--", repoURL, repoPath)
+	if err != nil {
+		return fmt.Errorf("git clone failed: %v", err)
+	}
+	debug.Printf("Successfully cloned repository: %s", output)
+	return nil
+}
+
+func (g execGit) LocalChanges(ctx context.Context, repoPath string) (int, error) {
+	output, err := g.run(ctx, repoPath, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return 0, err
+	}
+	return len(splitLines(output)), nil
+}
+
+func (g execGit) ResetHard(ctx context.Context, repoPath, commit string) error {
+	_, err := g.run(ctx, repoPath, "reset", "--hard", commit)
+	return err
+}
+
+func (g execGit) ResetKeep(ctx context.Context, repoPath, commit string) error {
+	_, err := g.run(ctx, repoPath, "reset", "--keep", commit, "--")
+	return err
+}
+
+func (g execGit) Fetch(ctx context.Context, repoPath string) error {
+	_, err := g.run(ctx, repoPath, "fetch", "--tags", "origin")
+	return err
+}
+
+func (g execGit) CurrentBranch(repoPath string) string {
+	branch, err := g.run(context.Background(), repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
+
+func (g execGit) DefaultBranch(repoPath string) string {
+	if ref, err := g.run(context.Background(), repoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(ref, "origin/")
+	}
+	for _, branch := range []string{"main", "master"} {
+		if g.hasRef(repoPath, "refs/remotes/origin/"+branch) {
+			return branch
+		}
+	}
+	return ""
+}
+
+func (g execGit) Tags(repoPath string) ([]string, error) {
+	output, err := g.run(context.Background(), repoPath, "tag", "--list")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(output), nil
+}
+
+func (g execGit) RemoteBranches(repoPath string) ([]string, error) {
+	output, err := g.run(context.Background(), repoPath, "branch", "--remotes", "--format=%(refname:short)")
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []string
+	for _, ref := range splitLines(output) {
+		branch, found := strings.CutPrefix(ref, "origin/")
+		if !found || branch == "HEAD" {
+			continue
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+func (g execGit) CheckoutBranch(ctx context.Context, repoPath, branch string) error {
+	if g.hasRef(repoPath, "refs/heads/"+branch) {
+		_, err := g.run(ctx, repoPath, "checkout", branch, "--")
+		return err
+	}
+	_, err := g.run(ctx, repoPath, "checkout", "-b", branch, "--track", "origin/"+branch, "--")
+	return err
+}
+
+func (g execGit) CheckoutBranchAt(ctx context.Context, repoPath, branch, commit string) error {
+	if _, err := g.run(ctx, repoPath, "checkout", "-B", branch, commit, "--"); err != nil {
+		return err
+	}
+	if _, err := g.run(ctx, repoPath, "branch", "--set-upstream-to=origin/"+branch, branch); err != nil {
+		debug.Printf("Warning: failed to set upstream for %s: %v", repoPath, err)
+	}
+	return nil
+}
+
+func (g execGit) CheckoutDetached(ctx context.Context, repoPath, commit string) error {
+	_, err := g.run(ctx, repoPath, "checkout", "--detach", commit, "--")
+	return err
+}
+
+func (g execGit) AheadBehind(ctx context.Context, repoPath, commit string) (int, int, error) {
+	output, err := g.run(ctx, repoPath, "rev-list", "--left-right", "--count", "HEAD..."+commit, "--")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", output)
+	}
+	ahead, _ := strconv.Atoi(fields[0])
+	behind, _ := strconv.Atoi(fields[1])
+	return ahead, behind, nil
+}
+
+func (g execGit) Log(ctx context.Context, repoPath, from, to string) ([]CommitInfo, error) {
+	format := "--format=" + logRecordSeparator + "%H" + logFieldSeparator + "%an" + logFieldSeparator + "%at" + logFieldSeparator + "%s"
+	output, err := g.run(ctx, repoPath, "log", format, "--shortstat", from+".."+to, "--")
+	if err != nil {
+		return nil, err
+	}
+	return parseGitLog(output), nil
+}
+
+func (g execGit) Stash(ctx context.Context, repoPath, message string) error {
+	_, err := g.run(ctx, repoPath, "stash", "push", "-m", message)
+	return err
+}
+
+func (g execGit) StashPop(ctx context.Context, repoPath string) error {
+	if _, err := g.run(ctx, repoPath, "stash", "pop"); err != nil {
+		// A conflicting pop keeps the stash, so drop the half-applied changes
+		g.run(ctx, repoPath, "reset", "--hard", "HEAD")
+		return err
+	}
+	return nil
+}
+
+// hasRef reports whether a full ref name exists
+func (execGit) hasRef(repoPath, ref string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref)
+	cmd.Dir = repoPath
+	return cmd.Run() == nil
+}
+
+// run runs a git command in dir and returns its trimmed combined output
+func (execGit) run(ctx context.Context, dir string, args ...string) (string, error) {
+	if !hasSystemGit() {
+		return "", errGitUnavailable
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		debug.Printf("git %s failed in %s: %s", strings.Join(args, " "), dir, string(output))
+		return "", fmt.Errorf("git %s failed: %v", args[0], err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// readOriginURL reads the origin URL straight from .git/config, which works without git
+func readOriginURL(repoPath string) string {
+	gitConfigPath := filepath.Join(repoPath, ".git", "config")
+	if content, err := os.ReadFile(gitConfigPath); err == nil {
+		lines := strings.Split(string(content), "\n")
+		for i, line := range lines {
+			if strings.Contains(line, "[remote \"origin\"]") && i+1 < len(lines) {
+				urlLine := strings.TrimSpace(lines[i+1])
+				if strings.HasPrefix(urlLine, "url = ") {
+					return strings.TrimPrefix(urlLine, "url = ")
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,482 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// goGit implements the git operations in pure Go, so the addon manager works on Macs
+// without the Xcode Command Line Tools
+type goGit struct{}
+
+func (goGit) Name() string {
+	return "built-in git"
+}
+
+func (goGit) RemoteURL(repoPath string) string {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return readOriginURL(repoPath)
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return ""
+	}
+	return remote.Config().URLs[0]
+}
+
+func (goGit) HeadCommit(repoPath string) (string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+func (g goGit) RemoteCommit(ctx context.Context, repoPath, pinKind, pinRef string) (string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := g.fetch(ctx, repo); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		debug.Printf("Fetch failed for %s, using the last fetched state: %v", repoPath, err)
+	}
+
+	switch pinKind {
+	case PinBranch:
+		return resolveReference(repo, plumbing.NewRemoteReferenceName("origin", pinRef))
+	case PinTag:
+		return resolveTag(repo, pinRef)
+	case PinCommit:
+		hash, err := repo.ResolveRevision(plumbing.Revision(pinRef))
+		if err != nil {
+			return "", err
+		}
+		return hash.String(), nil
+	}
+
+	var candidates []plumbing.ReferenceName
+	if upstream := g.upstream(repo); upstream != "" {
+		candidates = append(candidates, upstream)
+	}
+	candidates = append(candidates,
+		plumbing.NewRemoteHEADReferenceName("origin"),
+		plumbing.NewRemoteReferenceName("origin", "main"),
+		plumbing.NewRemoteReferenceName("origin", "master"),
+	)
+
+	for _, name := range candidates {
+		if commit, err := resolveReference(repo, name); err == nil {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("no remote branch found to update from")
+}
+
+func (g goGit) Pull(ctx context.Context, repoPath string) error {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	if !head.Name().IsBranch() {
+		return fmt.Errorf("git pull failed: not on a branch")
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	// go-git pulls the remote's HEAD unless told otherwise, so pull the branch's upstream
+	remoteBranch := head.Name()
+	if cfg, err := repo.Config(); err == nil {
+		if branch, ok := cfg.Branches[head.Name().Short()]; ok && branch.Merge != "" {
+			remoteBranch = branch.Merge
+		}
+	}
+
+	err = worktree.PullContext(ctx, &git.PullOptions{RemoteName: "origin", ReferenceName: remoteBranch})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("git pull failed: %v", err)
+	}
+	return nil
+}
+
+func (goGit) Clone(ctx context.Context, repoURL, repoPath string) error {
+	_, err := git.PlainCloneContext(ctx, repoPath, false, &git.CloneOptions{URL: repoURL, Tags: git.AllTags})
+	if err != nil {
+		return fmt.Errorf("git clone failed: %v", err)
+	}
+	return nil
+}
+
+func (goGit) LocalChanges(ctx context.Context, repoPath string) (int, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return 0, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return 0, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked {
+			continue
+		}
+		if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (goGit) ResetHard(ctx context.Context, repoPath, commit string) error {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Reset(&git.ResetOptions{Commit: plumbing.NewHash(commit), Mode: git.HardReset})
+}
+
+func (goGit) ResetKeep(ctx context.Context, repoPath, commit string) error {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(commit))
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	// A merge reset aborts instead of overwriting files with unstaged changes
+	return worktree.Reset(&git.ResetOptions{Commit: *hash, Mode: git.MergeReset})
+}
+
+func (g goGit) Fetch(ctx context.Context, repoPath string) error {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	return g.fetch(ctx, repo)
+}
+
+func (goGit) CurrentBranch(repoPath string) string {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return ""
+	}
+	head, err := repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return ""
+	}
+	return head.Name().Short()
+}
+
+func (goGit) DefaultBranch(repoPath string) string {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return ""
+	}
+	if ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false); err == nil && ref.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(ref.Target().Short(), "origin/")
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), false); err == nil {
+			return branch
+		}
+	}
+	return ""
+}
+
+func (goGit) Tags(repoPath string) ([]string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, ref.Name().Short())
+		return nil
+	})
+	return tags, err
+}
+
+func (goGit) RemoteBranches(repoPath string) ([]string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		branch, found := strings.CutPrefix(ref.Name().String(), "refs/remotes/origin/")
+		if found && branch != "HEAD" {
+			branches = append(branches, branch)
+		}
+		return nil
+	})
+	return branches, err
+}
+
+func (g goGit) CheckoutBranch(ctx context.Context, repoPath, branch string) error {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	localBranch := plumbing.NewBranchReferenceName(branch)
+	if _, err := repo.Reference(localBranch, false); err == nil {
+		return worktree.Checkout(&git.CheckoutOptions{Branch: localBranch})
+	}
+
+	remoteBranch, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if err != nil {
+		return fmt.Errorf("branch %s not found on origin", branch)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: localBranch, Hash: remoteBranch.Hash(), Create: true}); err != nil {
+		return err
+	}
+	g.setUpstream(repo, branch)
+	return nil
+}
+
+func (g goGit) CheckoutBranchAt(ctx context.Context, repoPath, branch, commit string) error {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(commit))
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	localBranch := plumbing.NewBranchReferenceName(branch)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(localBranch, *hash)); err != nil {
+		return err
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: localBranch}); err != nil {
+		return err
+	}
+	g.setUpstream(repo, branch)
+	return nil
+}
+
+func (goGit) CheckoutDetached(ctx context.Context, repoPath, commit string) error {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(commit))
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(&git.CheckoutOptions{Hash: *hash})
+}
+
+func (goGit) AheadBehind(ctx context.Context, repoPath, commit string) (int, int, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return 0, 0, err
+	}
+	local, err := ancestors(ctx, repo, head.Hash())
+	if err != nil {
+		return 0, 0, err
+	}
+	remote, err := ancestors(ctx, repo, plumbing.NewHash(commit))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ahead, behind := 0, 0
+	for hash := range local {
+		if !remote[hash] {
+			ahead++
+		}
+	}
+	for hash := range remote {
+		if !local[hash] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+func (goGit) Log(ctx context.Context, repoPath, from, to string) ([]CommitInfo, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := ancestors(ctx, repo, plumbing.NewHash(from))
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: plumbing.NewHash(to), Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	var commits []CommitInfo
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if seen[commit.Hash] {
+			return nil
+		}
+		info := CommitInfo{
+			Hash:    commit.Hash.String(),
+			Author:  commit.Author.Name,
+			Date:    commit.Author.When,
+			Subject: strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+		}
+		if stats, err := commit.StatsContext(ctx); err == nil {
+			info.FilesChanged = len(stats)
+		}
+		commits = append(commits, info)
+		return nil
+	})
+	return commits, err
+}
+
+// fetch fetches origin, treating an up-to-date repository as success
+func (goGit) fetch(ctx context.Context, repo *git.Repository) error {
+	err := repo.FetchContext(ctx, &git.FetchOptions{RemoteName: "origin", Tags: git.AllTags})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+// setUpstream makes a local branch track origin's branch of the same name
+func (goGit) setUpstream(repo *git.Repository, branch string) {
+	cfg, err := repo.Config()
+	if err == nil {
+		cfg.Branches[branch] = &config.Branch{Name: branch, Remote: "origin", Merge: plumbing.NewBranchReferenceName(branch)}
+		err = repo.SetConfig(cfg)
+	}
+	if err != nil {
+		debug.Printf("Warning: failed to set upstream of %s: %v", branch, err)
+	}
+}
+
+// ancestors returns the commits reachable from hash, including hash itself
+func ancestors(ctx context.Context, repo *git.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	iter, err := repo.Log(&git.LogOptions{From: hash})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[plumbing.Hash]bool)
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		seen[commit.Hash] = true
+		return nil
+	})
+	return seen, err
+}
+
+// upstream returns the remote-tracking ref of the checked out branch
+func (goGit) upstream(repo *git.Repository) plumbing.ReferenceName {
+	head, err := repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return ""
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return ""
+	}
+	branch, ok := cfg.Branches[head.Name().Short()]
+	if !ok || branch.Merge == "" || branch.Remote == "" {
+		return ""
+	}
+	return plumbing.NewRemoteReferenceName(branch.Remote, branch.Merge.Short())
+}
+
+// resolveReference returns the commit a reference points to, following symbolic refs
+func resolveReference(repo *git.Repository, name plumbing.ReferenceName) (string, error) {
+	ref, err := repo.Reference(name, true)
+	if err != nil {
+		return "", err
+	}
+	return ref.Hash().String(), nil
+}
+
+// resolveTag returns the commit a lightweight or annotated tag points to
+func resolveTag(repo *git.Repository, name string) (string, error) {
+	ref, err := repo.Tag(name)
+	if err != nil {
+		return "", err
+	}
+
+	tag, err := repo.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return ref.Hash().String(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := tag.Commit()
+	if err != nil {
+		return "", err
+	}
+	return commit.Hash.String(), nil
+}
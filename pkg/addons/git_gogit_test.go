@@ -0,0 +1,185 @@
+package addons
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFile writes a file in a go-git repository and commits it
+func commitFile(t *testing.T, repoPath, name, content string) string {
+	t.Helper()
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := worktree.Commit("Update "+name, &git.CommitOptions{Author: &object.Signature{Name: "Test", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash.String()
+}
+
+// newClonedRepo creates an origin repository with one commit and a clone of it
+func newClonedRepo(t *testing.T) (origin, clone string) {
+	t.Helper()
+	origin = filepath.Join(t.TempDir(), "origin")
+	if _, err := git.PlainInit(origin, false); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, origin, "Addon.lua", "v1\n")
+	commitFile(t, origin, "Addon.toc", "## Title: Addon\n")
+
+	clone = filepath.Join(t.TempDir(), "Addon")
+	if err := (goGit{}).Clone(context.Background(), origin, clone); err != nil {
+		t.Fatal(err)
+	}
+	return origin, clone
+}
+
+func TestGoGitAheadBehindAndLog(t *testing.T) {
+	ctx := context.Background()
+	origin, clone := newClonedRepo(t)
+	g := goGit{}
+
+	base, _ := g.HeadCommit(clone)
+	commitFile(t, origin, "Addon.lua", "v2\n")
+	remote := commitFile(t, origin, "Addon.lua", "v3\n")
+	commitFile(t, clone, "Local.lua", "local\n")
+
+	if err := g.Fetch(ctx, clone); err != nil {
+		t.Fatal(err)
+	}
+	ahead, behind, err := g.AheadBehind(ctx, clone, remote)
+	if err != nil || ahead != 1 || behind != 2 {
+		t.Errorf("AheadBehind() = %d, %d, %v, want 1, 2", ahead, behind, err)
+	}
+
+	commits, err := g.Log(ctx, clone, base, remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 || commits[0].Hash != remote || commits[0].Subject != "Update Addon.lua" || commits[0].FilesChanged != 1 {
+		t.Errorf("Log() = %+v", commits)
+	}
+}
+
+func TestGoGitStashPop(t *testing.T) {
+	ctx := context.Background()
+	origin, clone := newClonedRepo(t)
+	g := goGit{}
+
+	os.WriteFile(filepath.Join(clone, "Addon.toc"), []byte("## Title: Edited\n"), 0644)
+	if err := g.Stash(ctx, clone, "before update"); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := g.LocalChanges(ctx, clone); count != 0 {
+		t.Errorf("%d local changes left after stashing", count)
+	}
+
+	commitFile(t, origin, "Addon.lua", "v2\n")
+	if err := g.Pull(ctx, clone); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.StashPop(ctx, clone); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(clone, "Addon.toc")); string(content) != "## Title: Edited\n" {
+		t.Errorf("Addon.toc = %q after popping the stash", content)
+	}
+	if _, err := os.Stat(goGitStashPath(clone)); !os.IsNotExist(err) {
+		t.Errorf("stash left behind after popping it")
+	}
+
+	// A file the update also changed can't be restored without a merge
+	os.WriteFile(filepath.Join(clone, "Addon.lua"), []byte("edited\n"), 0644)
+	if err := g.Stash(ctx, clone, "before update"); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, origin, "Addon.lua", "v3\n")
+	if err := g.Pull(ctx, clone); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.StashPop(ctx, clone); err == nil {
+		t.Errorf("StashPop() succeeded despite a conflict")
+	}
+	if content, _ := os.ReadFile(filepath.Join(clone, "Addon.lua")); string(content) != "v3\n" {
+		t.Errorf("Addon.lua = %q after a conflicting pop", content)
+	}
+	if _, err := os.Stat(goGitStashPath(clone)); err != nil {
+		t.Errorf("conflicting changes were not kept stashed: %v", err)
+	}
+}
+
+func TestGoGitBranchCheckout(t *testing.T) {
+	ctx := context.Background()
+	origin, clone := newClonedRepo(t)
+	g := goGit{}
+
+	first, _ := g.HeadCommit(clone)
+	defaultBranch := g.CurrentBranch(clone)
+	if defaultBranch == "" {
+		t.Fatal("clone is not on a branch")
+	}
+
+	originRepo, _ := git.PlainOpen(origin)
+	originWorktree, _ := originRepo.Worktree()
+	if err := originWorktree.Checkout(&git.CheckoutOptions{Branch: "refs/heads/dev", Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	dev := commitFile(t, origin, "Dev.lua", "dev\n")
+
+	if err := g.Fetch(ctx, clone); err != nil {
+		t.Fatal(err)
+	}
+	branches, _ := g.RemoteBranches(clone)
+	if !reflect.DeepEqual(branches, []string{"dev", defaultBranch}) && !reflect.DeepEqual(branches, []string{defaultBranch, "dev"}) {
+		t.Errorf("RemoteBranches() = %v", branches)
+	}
+
+	if err := g.CheckoutBranch(ctx, clone, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := g.HeadCommit(clone); g.CurrentBranch(clone) != "dev" || head != dev {
+		t.Errorf("on %q at %s after checking out dev", g.CurrentBranch(clone), head)
+	}
+
+	if err := g.CheckoutBranchAt(ctx, clone, "dev", first); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := g.HeadCommit(clone); head != first {
+		t.Errorf("dev at %s, want %s", head, first)
+	}
+
+	if err := g.CheckoutDetached(ctx, clone, dev); err != nil {
+		t.Fatal(err)
+	}
+	if branch := g.CurrentBranch(clone); branch != "" {
+		t.Errorf("CurrentBranch() = %q with a detached HEAD", branch)
+	}
+}
+
+func TestSortTagsNewestFirst(t *testing.T) {
+	tags := []string{"v1.2.0", "beta", "v1.10.0", "1.9", "alpha", "v2.0.0-rc1"}
+	sortTagsNewestFirst(tags)
+	want := []string{"v2.0.0-rc1", "v1.10.0", "1.9", "v1.2.0", "beta", "alpha"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("got %v, want %v", tags, want)
+	}
+}
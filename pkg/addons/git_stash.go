@@ -0,0 +1,188 @@
+package addons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// go-git has no stash, so the built-in backend copies the changed files into .git instead
+const goGitStashDir = "turtlesilicon-stash"
+
+// goGitStash describes the changes put aside by goGit.Stash
+type goGitStash struct {
+	Message string   `json:"message"`
+	Base    string   `json:"base"`
+	Changed []string `json:"changed"`
+	Deleted []string `json:"deleted"`
+}
+
+func goGitStashPath(repoPath string) string {
+	return filepath.Join(repoPath, ".git", goGitStashDir)
+}
+
+func (goGit) Stash(ctx context.Context, repoPath, message string) error {
+	stashPath := goGitStashPath(repoPath)
+	if _, err := os.Stat(stashPath); err == nil {
+		return fmt.Errorf("changes from an earlier update are still stashed in %s", stashPath)
+	}
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return err
+	}
+
+	stash := goGitStash{Message: message, Base: head.Hash().String()}
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked || (fileStatus.Worktree == git.Unmodified && fileStatus.Staging == git.Unmodified) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(repoPath, path))
+		if os.IsNotExist(err) {
+			stash.Deleted = append(stash.Deleted, path)
+			continue
+		}
+		if err != nil {
+			os.RemoveAll(stashPath)
+			return err
+		}
+		target := filepath.Join(stashPath, "files", path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			os.RemoveAll(stashPath)
+			return err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			os.RemoveAll(stashPath)
+			return err
+		}
+		stash.Changed = append(stash.Changed, path)
+	}
+	sort.Strings(stash.Changed)
+	sort.Strings(stash.Deleted)
+
+	data, err := json.MarshalIndent(stash, "", "  ")
+	if err == nil {
+		os.MkdirAll(stashPath, 0755)
+		err = os.WriteFile(filepath.Join(stashPath, "stash.json"), data, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(stashPath)
+		return fmt.Errorf("failed to save stash: %v", err)
+	}
+
+	return worktree.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.HardReset})
+}
+
+func (goGit) StashPop(ctx context.Context, repoPath string) error {
+	stashPath := goGitStashPath(repoPath)
+	data, err := os.ReadFile(filepath.Join(stashPath, "stash.json"))
+	if err != nil {
+		return fmt.Errorf("no stash found: %v", err)
+	}
+	var stash goGitStash
+	if err := json.Unmarshal(data, &stash); err != nil {
+		return fmt.Errorf("invalid stash %s: %v", stashPath, err)
+	}
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	// Without a merge, a stashed file can only be restored if the update left it alone
+	if head.Hash().String() != stash.Base {
+		var conflicts []string
+		for _, path := range append(append([]string{}, stash.Changed...), stash.Deleted...) {
+			changed, err := fileChangedBetween(repo, plumbing.NewHash(stash.Base), head.Hash(), path)
+			if err != nil {
+				return err
+			}
+			if changed {
+				conflicts = append(conflicts, path)
+			}
+		}
+		if len(conflicts) > 0 {
+			debug.Printf("Stashed changes in %s kept in %s", repoPath, stashPath)
+			return fmt.Errorf("the update also changed %s", strings.Join(conflicts, ", "))
+		}
+	}
+
+	for _, path := range stash.Changed {
+		content, err := os.ReadFile(filepath.Join(stashPath, "files", path))
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(repoPath, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return err
+		}
+	}
+	for _, path := range stash.Deleted {
+		if err := os.Remove(filepath.Join(repoPath, path)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.RemoveAll(stashPath)
+}
+
+// fileChangedBetween reports whether a file differs between two commits
+func fileChangedBetween(repo *git.Repository, from, to plumbing.Hash, path string) (bool, error) {
+	fromHash, err := fileHashAt(repo, from, path)
+	if err != nil {
+		return false, err
+	}
+	toHash, err := fileHashAt(repo, to, path)
+	if err != nil {
+		return false, err
+	}
+	return fromHash != toHash, nil
+}
+
+// fileHashAt returns the blob hash of a file in a commit, or the zero hash if it's missing
+func fileHashAt(repo *git.Repository, commitHash plumbing.Hash, path string) (plumbing.Hash, error) {
+	commit, err := repo.CommitObject(commitHash)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	file, err := tree.File(filepath.ToSlash(path))
+	if err == object.ErrFileNotFound {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return file.Hash, nil
+}
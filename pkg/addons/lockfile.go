@@ -0,0 +1,423 @@
+package addons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+)
+
+const lockfileFormatVersion = 1
+
+// lockTypeLocal marks addons without a known source; they can only be installed by hand
+const lockTypeLocal = "local"
+
+const (
+	LockActionInstall  = "install"
+	LockActionCheckout = "checkout"
+	LockActionReplace  = "replace"
+	LockActionRemove   = "remove"
+	LockActionManual   = "manual"
+)
+
+// Lockfile pins a set of addons to exact sources so the same set can be reproduced elsewhere
+type Lockfile struct {
+	Version     int         `json:"version"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	WoWVersion  string      `json:"wow_version,omitempty"`
+	Addons      []LockEntry `json:"addons"`
+}
+
+// LockEntry is a single addon folder in a lockfile
+type LockEntry struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	URL    string `json:"url,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// LockChange is one step needed to make the installed addons match a lockfile
+type LockChange struct {
+	Action string
+	Name   string
+	Detail string
+	Entry  *LockEntry
+}
+
+// String returns a human-readable description of the change
+func (c LockChange) String() string {
+	switch c.Action {
+	case LockActionInstall:
+		return fmt.Sprintf("+ %s: install %s", c.Name, c.Detail)
+	case LockActionCheckout:
+		return fmt.Sprintf("~ %s: %s", c.Name, c.Detail)
+	case LockActionReplace:
+		return fmt.Sprintf("~ %s: reinstall (%s)", c.Name, c.Detail)
+	case LockActionRemove:
+		return fmt.Sprintf("- %s: remove", c.Name)
+	case LockActionManual:
+		return fmt.Sprintf("! %s: not installed and has no source, install it manually", c.Name)
+	default:
+		return fmt.Sprintf("? %s: %s", c.Name, c.Detail)
+	}
+}
+
+// BuildLockfile records the installed addons with their exact source revision
+func (am *AddonManager) BuildLockfile() *Lockfile {
+	lockfile := &Lockfile{
+		Version:     lockfileFormatVersion,
+		GeneratedAt: time.Now(),
+	}
+	if am.gameVersion != nil {
+		lockfile.WoWVersion = am.gameVersion.WoWVersion
+	}
+
+	store := loadAddonStore()
+	for _, addon := range am.addons {
+		entry := LockEntry{Name: addon.Name, Type: lockTypeLocal}
+
+		switch {
+		case addon.HasGitRepo:
+			entry.Type = SourceTypeGit
+			entry.URL = addon.GitRemoteURL
+			entry.Branch = am.getCurrentBranch(addon.Path)
+			entry.Commit = am.getLocalCommit(addon.Path)
+		case addon.SourceType == SourceTypeZip:
+			if source := store.Sources[filepath.Clean(addon.Path)]; source != nil {
+				entry.Type = SourceTypeZip
+				entry.URL = source.URL
+				entry.Hash = source.Hash
+			}
+		}
+
+		lockfile.Addons = append(lockfile.Addons, entry)
+	}
+
+	sort.Slice(lockfile.Addons, func(i, j int) bool {
+		return strings.ToLower(lockfile.Addons[i].Name) < strings.ToLower(lockfile.Addons[j].Name)
+	})
+	return lockfile
+}
+
+// Write encodes the lockfile as indented JSON
+func (lf *Lockfile) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(lf, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadLockfile decodes and validates a lockfile
+func ReadLockfile(r io.Reader) (*Lockfile, error) {
+	var lockfile Lockfile
+	if err := json.NewDecoder(r).Decode(&lockfile); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile: %v", err)
+	}
+
+	if lockfile.Version > lockfileFormatVersion {
+		return nil, fmt.Errorf("lockfile version %d is newer than this version of TurtleSilicon supports", lockfile.Version)
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range lockfile.Addons {
+		if entry.Name == "" || entry.Name != filepath.Base(entry.Name) || strings.HasPrefix(entry.Name, ".") {
+			return nil, fmt.Errorf("lockfile contains an invalid addon name: %q", entry.Name)
+		}
+		if seen[strings.ToLower(entry.Name)] {
+			return nil, fmt.Errorf("lockfile lists %s more than once", entry.Name)
+		}
+		seen[strings.ToLower(entry.Name)] = true
+
+		if (entry.Type == SourceTypeGit || entry.Type == SourceTypeZip) && entry.URL == "" {
+			return nil, fmt.Errorf("lockfile entry %s has no source URL", entry.Name)
+		}
+		if err := validateLockEntry(entry); err != nil {
+			return nil, fmt.Errorf("lockfile entry %s: %v", entry.Name, err)
+		}
+	}
+
+	return &lockfile, nil
+}
+
+var commitHashPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,64}$`)
+
+// validateLockEntry checks the values of a lockfile entry that end up as git arguments, as
+// a shared lockfile could otherwise smuggle in options such as --upload-pack. Unknown source
+// types are rejected so they can't bypass these checks.
+func validateLockEntry(entry LockEntry) error {
+	switch entry.Type {
+	case SourceTypeGit:
+		if !isAllowedRepoURL(entry.URL) {
+			return fmt.Errorf("unsupported repository URL %q, expected https, ssh or git@", entry.URL)
+		}
+		if entry.Branch != "" && !isValidBranchName(entry.Branch) {
+			return fmt.Errorf("invalid branch name %q", entry.Branch)
+		}
+		if entry.Commit != "" && !commitHashPattern.MatchString(entry.Commit) {
+			return fmt.Errorf("invalid commit %q", entry.Commit)
+		}
+	case SourceTypeZip:
+		parsedURL, err := url.Parse(entry.URL)
+		if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+			return fmt.Errorf("unsupported archive URL %q, expected http or https", entry.URL)
+		}
+	case lockTypeLocal:
+	default:
+		return fmt.Errorf("unsupported source type %q", entry.Type)
+	}
+	return nil
+}
+
+// isAllowedRepoURL accepts https and ssh URLs and scp-like git@host:path addresses
+func isAllowedRepoURL(repoURL string) bool {
+	if repoURL == "" || strings.HasPrefix(repoURL, "-") || strings.ContainsAny(repoURL, " \t\r\n") {
+		return false
+	}
+	if strings.HasPrefix(repoURL, "git@") {
+		host, path, found := strings.Cut(strings.TrimPrefix(repoURL, "git@"), ":")
+		return found && host != "" && path != "" && !strings.HasPrefix(host, "-") && !strings.HasPrefix(path, "-")
+	}
+	parsedURL, err := url.Parse(repoURL)
+	if err != nil || parsedURL.Host == "" || strings.HasPrefix(parsedURL.Hostname(), "-") {
+		return false
+	}
+	return parsedURL.Scheme == "https" || parsedURL.Scheme == "ssh"
+}
+
+// isValidBranchName applies the rules of git check-ref-format --branch
+func isValidBranchName(branch string) bool {
+	if branch == "" || branch == "@" || strings.HasPrefix(branch, "-") || strings.HasPrefix(branch, "/") ||
+		strings.HasSuffix(branch, "/") || strings.HasSuffix(branch, ".") ||
+		strings.Contains(branch, "..") || strings.Contains(branch, "//") || strings.Contains(branch, "@{") {
+		return false
+	}
+	for _, r := range branch {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, component := range strings.Split(branch, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+	return true
+}
+
+// PlanLockfile compares the installed addons with a lockfile and returns the changes needed
+// to match it. The installed addons must have been scanned first.
+func (am *AddonManager) PlanLockfile(lockfile *Lockfile) []LockChange {
+	installed := make(map[string]*Addon)
+	for i := range am.addons {
+		installed[strings.ToLower(am.addons[i].Name)] = &am.addons[i]
+	}
+
+	store := loadAddonStore()
+	var changes []LockChange
+	locked := make(map[string]bool)
+
+	for i := range lockfile.Addons {
+		entry := &lockfile.Addons[i]
+		locked[strings.ToLower(entry.Name)] = true
+		addon := installed[strings.ToLower(entry.Name)]
+
+		if addon == nil {
+			if entry.Type == lockTypeLocal {
+				changes = append(changes, LockChange{Action: LockActionManual, Name: entry.Name, Entry: entry})
+			} else {
+				changes = append(changes, LockChange{Action: LockActionInstall, Name: entry.Name, Detail: describeLockEntry(entry), Entry: entry})
+			}
+			continue
+		}
+
+		switch entry.Type {
+		case SourceTypeGit:
+			if !addon.HasGitRepo {
+				changes = append(changes, LockChange{Action: LockActionReplace, Name: entry.Name, Detail: "not a git checkout", Entry: entry})
+			} else if !sameRepoURL(addon.GitRemoteURL, entry.URL) {
+				changes = append(changes, LockChange{Action: LockActionReplace, Name: entry.Name, Detail: fmt.Sprintf("origin is %s", addon.GitRemoteURL), Entry: entry})
+			} else if entry.Commit != "" && addon.LocalCommit != entry.Commit {
+				changes = append(changes, LockChange{Action: LockActionCheckout, Name: entry.Name, Detail: fmt.Sprintf("%s → %s", shortCommit(addon.LocalCommit), describeLockEntry(entry)), Entry: entry})
+			} else if entry.Branch != "" && am.getCurrentBranch(addon.Path) != entry.Branch {
+				changes = append(changes, LockChange{Action: LockActionCheckout, Name: entry.Name, Detail: fmt.Sprintf("switch to %s", entry.Branch), Entry: entry})
+			}
+		case SourceTypeZip:
+			source := store.Sources[filepath.Clean(addon.Path)]
+			if addon.HasGitRepo || source == nil || source.Type != SourceTypeZip {
+				changes = append(changes, LockChange{Action: LockActionReplace, Name: entry.Name, Detail: "not installed from this archive", Entry: entry})
+			} else if source.URL != entry.URL || (entry.Hash != "" && source.Hash != entry.Hash) {
+				changes = append(changes, LockChange{Action: LockActionReplace, Name: entry.Name, Detail: "archive differs", Entry: entry})
+			}
+		}
+	}
+
+	for _, addon := range am.addons {
+		if !locked[strings.ToLower(addon.Name)] {
+			changes = append(changes, LockChange{Action: LockActionRemove, Name: addon.Name})
+		}
+	}
+
+	return changes
+}
+
+// ApplyLockfile performs the planned changes and returns the errors of those that failed
+func (am *AddonManager) ApplyLockfile(ctx context.Context, changes []LockChange, updateProgress func(string)) []error {
+	addonsPath, err := am.addonsPath()
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	installedArchives := make(map[string]bool)
+
+	for i, change := range changes {
+		if updateProgress != nil {
+			updateProgress(fmt.Sprintf("%s %s (%d/%d)...", change.Action, change.Name, i+1, len(changes)))
+		}
+
+		addonPath := filepath.Join(addonsPath, change.Name)
+		var err error
+
+		switch change.Action {
+		case LockActionRemove:
+			err = am.removeLockedAddon(addonPath)
+		case LockActionManual:
+			debug.Printf("Skipping %s: no source recorded in lockfile", change.Name)
+			continue
+		case LockActionCheckout:
+			err = am.checkoutLockEntry(addonPath, change.Entry)
+		case LockActionInstall, LockActionReplace:
+			if change.Entry.Type == SourceTypeZip {
+				// Multi-folder packs list every folder with the same archive, install it once
+				if installedArchives[change.Entry.URL] {
+					continue
+				}
+				installedArchives[change.Entry.URL] = true
+				err = am.installLockedZip(ctx, change.Entry)
+				break
+			}
+
+			if change.Action == LockActionReplace {
+				if err = am.removeLockedAddon(addonPath); err != nil {
+					break
+				}
+			}
+			if err = am.cloneRepositoryAs(ctx, change.Entry.URL, change.Name); err == nil {
+				err = am.checkoutLockEntry(addonPath, change.Entry)
+			}
+		}
+
+		if err != nil {
+			debug.Printf("Lockfile: failed to %s %s: %v", change.Action, change.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %v", change.Name, err))
+		}
+	}
+
+	return errs
+}
+
+// checkoutLockEntry moves a git checkout to the branch and commit recorded in the lockfile
+func (am *AddonManager) checkoutLockEntry(addonPath string, entry *LockEntry) error {
+	if entry.Commit == "" && entry.Branch == "" {
+		return nil
+	}
+
+	// Resolving the target fetches origin first
+	kind, ref := PinCommit, entry.Commit
+	if ref == "" {
+		kind, ref = PinBranch, entry.Branch
+	}
+	target, err := am.git().RemoteCommit(context.Background(), addonPath, kind, ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s %s: %v", kind, ref, err)
+	}
+
+	if entry.Branch == "" {
+		return am.git().CheckoutDetached(context.Background(), addonPath, target)
+	}
+	// Point the local branch at the locked commit so later pulls continue from there
+	return am.git().CheckoutBranchAt(context.Background(), addonPath, entry.Branch, target)
+}
+
+// installLockedZip installs the archive from a lockfile entry and warns if it changed since
+func (am *AddonManager) installLockedZip(ctx context.Context, entry *LockEntry) error {
+	folders, err := am.installZip(ctx, entry.URL, true)
+	if err != nil {
+		return err
+	}
+
+	if entry.Hash == "" || len(folders) == 0 {
+		return nil
+	}
+
+	addonsPath, err := am.addonsPath()
+	if err != nil {
+		return err
+	}
+	if source := getAddonSource(filepath.Join(addonsPath, folders[0])); source != nil && source.Hash != entry.Hash {
+		return fmt.Errorf("installed, but the archive at %s has changed since the lockfile was created", entry.URL)
+	}
+	return nil
+}
+
+// removeLockedAddon deletes an addon folder and forgets its recorded source
+func (am *AddonManager) removeLockedAddon(addonPath string) error {
+	if err := os.RemoveAll(addonPath); err != nil {
+		return err
+	}
+	if err := removeAddonSource(addonPath); err != nil {
+		debug.Printf("Warning: failed to forget source for %s: %v", addonPath, err)
+	}
+	return nil
+}
+
+// describeLockEntry summarizes where a lockfile entry is installed from
+func describeLockEntry(entry *LockEntry) string {
+	switch entry.Type {
+	case SourceTypeGit:
+		description := entry.URL
+		if entry.Branch != "" {
+			description += "@" + entry.Branch
+		}
+		if entry.Commit != "" {
+			description += " " + shortCommit(entry.Commit)
+		}
+		return description
+	case SourceTypeZip:
+		return entry.URL
+	default:
+		return entry.Type
+	}
+}
+
+// sameRepoURL compares repository URLs, ignoring a trailing slash or .git suffix
+func sameRepoURL(a, b string) bool {
+	normalize := func(u string) string {
+		return strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(u)), "/"), ".git")
+	}
+	return normalize(a) == normalize(b)
+}
+
+// shortCommit abbreviates a commit hash for display
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "(none)"
+	}
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
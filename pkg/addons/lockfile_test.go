@@ -0,0 +1,88 @@
+package addons
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadLockfile(t *testing.T) {
+	valid := `{"version": 1, "addons": [{"name": "pfQuest", "type": "git", "url": "https://github.com/shagu/pfQuest", "commit": "abc123"}]}`
+	lockfile, err := ReadLockfile(strings.NewReader(valid))
+	if err != nil {
+		t.Fatalf("ReadLockfile() error = %v", err)
+	}
+	if len(lockfile.Addons) != 1 || lockfile.Addons[0].Commit != "abc123" {
+		t.Errorf("ReadLockfile() = %+v", lockfile)
+	}
+
+	invalid := map[string]string{
+		"path traversal": `{"version": 1, "addons": [{"name": "../WTF", "type": "local"}]}`,
+		"duplicate":      `{"version": 1, "addons": [{"name": "A", "type": "local"}, {"name": "a", "type": "local"}]}`,
+		"missing url":    `{"version": 1, "addons": [{"name": "A", "type": "git"}]}`,
+		"newer format":   `{"version": 99, "addons": []}`,
+		"option url":     `{"version": 1, "addons": [{"name": "A", "type": "git", "url": "--upload-pack=touch /tmp/x"}]}`,
+		"file url":       `{"version": 1, "addons": [{"name": "A", "type": "git", "url": "file:///etc"}]}`,
+		"ext url":        `{"version": 1, "addons": [{"name": "A", "type": "git", "url": "ext::sh -c touch% /tmp/x"}]}`,
+		"option branch":  `{"version": 1, "addons": [{"name": "A", "type": "git", "url": "https://example.com/a", "branch": "--orphan"}]}`,
+		"bad branch":     `{"version": 1, "addons": [{"name": "A", "type": "git", "url": "https://example.com/a", "branch": "a..b"}]}`,
+		"option commit":  `{"version": 1, "addons": [{"name": "A", "type": "git", "url": "https://example.com/a", "commit": "--detach"}]}`,
+		"ssh option":     `{"version": 1, "addons": [{"name": "A", "type": "git", "url": "git@-oProxyCommand=x:a/b"}]}`,
+		"zip file url":   `{"version": 1, "addons": [{"name": "A", "type": "zip", "url": "/etc/passwd.zip"}]}`,
+		"unknown type":   `{"version": 1, "addons": [{"name": "A", "type": "svn", "url": "file:///etc", "branch": "--orphan"}]}`,
+		"missing type":   `{"version": 1, "addons": [{"name": "A"}]}`,
+	}
+	for name, content := range invalid {
+		if _, err := ReadLockfile(strings.NewReader(content)); err == nil {
+			t.Errorf("ReadLockfile() with %s should fail", name)
+		}
+	}
+}
+
+func TestValidateLockEntryAccepts(t *testing.T) {
+	valid := []LockEntry{
+		{Name: "A", Type: SourceTypeGit, URL: "https://github.com/shagu/pfQuest", Branch: "feature/new-ui", Commit: "0123456789abcdef0123456789abcdef01234567"},
+		{Name: "A", Type: SourceTypeGit, URL: "ssh://git@github.com/shagu/pfQuest.git"},
+		{Name: "A", Type: SourceTypeGit, URL: "git@github.com:shagu/pfQuest.git", Branch: "master"},
+		{Name: "A", Type: SourceTypeZip, URL: "https://example.com/addon.zip"},
+	}
+	for _, entry := range valid {
+		if err := validateLockEntry(entry); err != nil {
+			t.Errorf("validateLockEntry(%+v) error = %v", entry, err)
+		}
+	}
+}
+
+func TestPlanLockfile(t *testing.T) {
+	am := &AddonManager{addons: []Addon{
+		{Name: "pfQuest", HasGitRepo: true, GitRemoteURL: "https://github.com/shagu/pfQuest.git", LocalCommit: "old"},
+		{Name: "ShaguTweaks", HasGitRepo: true, GitRemoteURL: "https://github.com/shagu/ShaguTweaks", LocalCommit: "same"},
+		{Name: "Extra"},
+	}}
+
+	lockfile := &Lockfile{Addons: []LockEntry{
+		{Name: "pfQuest", Type: SourceTypeGit, URL: "https://github.com/shagu/pfQuest", Commit: "new"},
+		{Name: "ShaguTweaks", Type: SourceTypeGit, URL: "https://github.com/shagu/ShaguTweaks/", Commit: "same"},
+		{Name: "Bagnon", Type: SourceTypeGit, URL: "https://github.com/example/Bagnon"},
+		{Name: "Handmade", Type: lockTypeLocal},
+	}}
+
+	got := make(map[string]string)
+	for _, change := range am.PlanLockfile(lockfile) {
+		got[change.Name] = change.Action
+	}
+
+	want := map[string]string{
+		"pfQuest":  LockActionCheckout,
+		"Bagnon":   LockActionInstall,
+		"Handmade": LockActionManual,
+		"Extra":    LockActionRemove,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("PlanLockfile() = %v, want %v", got, want)
+	}
+	for name, action := range want {
+		if got[name] != action {
+			t.Errorf("PlanLockfile()[%s] = %q, want %q", name, got[name], action)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+)
+
+const (
+	PinBranch = "branch"
+	PinTag    = "tag"
+	PinCommit = "commit"
+)
+
+// recordPreviousCommit remembers the commit an addon was on before its checkout changed
+func (am *AddonManager) recordPreviousCommit(addon *Addon, commit string) {
+	if err := updateGitSource(addon.Path, addon.GitRemoteURL, func(source *AddonSource) {
+		source.PreviousCommit = commit
+	}); err != nil {
+		debug.Printf("Warning: failed to record previous commit for %s: %v", addon.Name, err)
+		return
+	}
+	addon.PreviousCommit = commit
+}
+
+// ListTags returns the addon repository's tags, newest version first
+func (am *AddonManager) ListTags(addon *Addon) ([]string, error) {
+	if err := am.git().Fetch(context.Background(), addon.Path); err != nil {
+		debug.Printf("Failed to fetch tags for %s, listing local tags: %v", addon.Name, err)
+	}
+
+	tags, err := am.git().Tags(addon.Path)
+	if err != nil {
+		return nil, err
+	}
+	sortTagsNewestFirst(tags)
+	return tags, nil
+}
+
+// ListBranches returns the branches available on the addon's origin remote
+func (am *AddonManager) ListBranches(addon *Addon) ([]string, error) {
+	branches, err := am.git().RemoteBranches(addon.Path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// sortTagsNewestFirst sorts version tags from newest to oldest, followed by any other tags in
+// reverse alphabetical order
+func sortTagsNewestFirst(tags []string) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		vi, errI := utils.ParseVersion(tags[i])
+		vj, errJ := utils.ParseVersion(tags[j])
+		switch {
+		case errI == nil && errJ == nil:
+			if c := vi.Compare(vj); c != 0 {
+				return c > 0
+			}
+			return tags[i] > tags[j]
+		case errI == nil || errJ == nil:
+			return errI == nil
+		default:
+			return tags[i] > tags[j]
+		}
+	})
+}
+
+// PinAddon checks out the given branch, tag or commit and keeps the addon on it during updates
+func (am *AddonManager) PinAddon(addon *Addon, kind, ref string) error {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return fmt.Errorf("no %s given", kind)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid %s %q", kind, ref)
+	}
+
+	before := am.getLocalCommit(addon.Path)
+	ctx := context.Background()
+
+	var err error
+	switch kind {
+	case PinBranch:
+		if fetchErr := am.git().Fetch(ctx, addon.Path); fetchErr != nil {
+			debug.Printf("Failed to fetch %s before pinning: %v", addon.Name, fetchErr)
+		}
+		// Checking out a remote-only branch creates a local branch tracking it
+		err = am.git().CheckoutBranch(ctx, addon.Path, ref)
+	case PinTag, PinCommit:
+		// Resolving the tag or commit fetches origin first
+		var target string
+		if target, err = am.git().RemoteCommit(ctx, addon.Path, kind, ref); err == nil {
+			err = am.git().CheckoutDetached(ctx, addon.Path, target)
+		}
+	default:
+		return fmt.Errorf("unknown pin type %q", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check out %s %s: %v", kind, ref, err)
+	}
+
+	after := am.getLocalCommit(addon.Path)
+	if err := updateGitSource(addon.Path, addon.GitRemoteURL, func(source *AddonSource) {
+		source.PinnedRef = ref
+		source.PinnedKind = kind
+		if before != "" && before != after {
+			source.PreviousCommit = before
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to save pin: %v", err)
+	}
+
+	debug.Printf("Pinned %s to %s %s (%s)", addon.Name, kind, ref, shortCommit(after))
+	addon.PinnedRef = ref
+	addon.PinnedKind = kind
+	addon.LocalCommit = after
+	if before != "" && before != after {
+		addon.PreviousCommit = before
+	}
+	return nil
+}
+
+// UnpinAddon removes the pin and returns the addon to the remote's default branch
+func (am *AddonManager) UnpinAddon(addon *Addon) error {
+	before := am.getLocalCommit(addon.Path)
+
+	branch := am.git().DefaultBranch(addon.Path)
+	if branch == "" {
+		return fmt.Errorf("could not determine the default branch of %s", addon.Name)
+	}
+	if err := am.git().CheckoutBranch(context.Background(), addon.Path, branch); err != nil {
+		return fmt.Errorf("failed to check out %s: %v", branch, err)
+	}
+
+	after := am.getLocalCommit(addon.Path)
+	if err := updateGitSource(addon.Path, addon.GitRemoteURL, func(source *AddonSource) {
+		source.PinnedRef = ""
+		source.PinnedKind = ""
+		if before != "" && before != after {
+			source.PreviousCommit = before
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to save pin: %v", err)
+	}
+
+	debug.Printf("Unpinned %s, now on %s", addon.Name, branch)
+	addon.PinnedRef = ""
+	addon.PinnedKind = ""
+	addon.LocalCommit = after
+	return nil
+}
+
+// RollbackAddon returns the addon to the commit it was on before its last update and pins it
+// there, so the next update doesn't bring the bad version straight back. Rolling back twice
+// returns to where it started.
+func (am *AddonManager) RollbackAddon(addon *Addon) error {
+	if addon.PreviousCommit == "" {
+		return fmt.Errorf("no previous commit recorded for %s", addon.Name)
+	}
+
+	current := am.getLocalCommit(addon.Path)
+	target := addon.PreviousCommit
+
+	var err error
+	if am.getCurrentBranch(addon.Path) != "" {
+		// --keep refuses to run if it would overwrite local modifications
+		err = am.git().ResetKeep(context.Background(), addon.Path, target)
+	} else {
+		err = am.git().CheckoutDetached(context.Background(), addon.Path, target)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to roll back to %s: %v", shortCommit(target), err)
+	}
+
+	// Hold the addon at the commit it was rolled back to until the user unpins it
+	if err := updateGitSource(addon.Path, addon.GitRemoteURL, func(source *AddonSource) {
+		source.PinnedKind = PinCommit
+		source.PinnedRef = target
+	}); err != nil {
+		debug.Printf("Warning: failed to update pin for %s: %v", addon.Name, err)
+	}
+	addon.PinnedKind = PinCommit
+	addon.PinnedRef = target
+	am.recordPreviousCommit(addon, current)
+	addon.LocalCommit = target
+	addon.RemoteCommit = target
+	addon.NeedsUpdate = false
+	debug.Printf("Rolled back %s from %s to %s", addon.Name, shortCommit(current), shortCommit(target))
+	return nil
+}
+
+// splitLines splits command output into its non-empty lines
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
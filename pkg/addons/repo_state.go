@@ -0,0 +1,156 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+)
+
+// Update strategies for git addons with local changes or a diverged history
+const (
+	StrategyPull  = ""      // plain pull, refused when the checkout has local changes or diverged
+	StrategyStash = "stash" // stash local edits, update, then re-apply them
+	StrategyReset = "reset" // discard local edits and commits and reset to the remote
+	StrategySkip  = "skip"  // leave addons with local changes or a diverged history alone
+)
+
+// ErrSkipped is returned for addons left alone by StrategySkip
+var ErrSkipped = errors.New("skipped")
+
+// HasLocalState reports whether a plain pull could fail or lose work for this addon
+func (a *Addon) HasLocalState() bool {
+	return a.LocalChanges > 0 || a.Diverged
+}
+
+// describeLocalState explains why an addon can't be updated with a plain pull
+func (a *Addon) describeLocalState() string {
+	var reasons []string
+	if a.LocalChanges > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d locally modified files", a.LocalChanges))
+	}
+	if a.Diverged {
+		reasons = append(reasons, fmt.Sprintf("history diverged from the remote (%d local, %d remote commits)", a.Ahead, a.Behind))
+	}
+	return strings.Join(reasons, " and ")
+}
+
+// countLocalChanges returns the number of tracked files with uncommitted changes
+func (am *AddonManager) countLocalChanges(ctx context.Context, addonPath string) int {
+	count, err := am.git().LocalChanges(ctx, addonPath)
+	if err != nil {
+		debug.Printf("Failed to check local changes in %s: %v", addonPath, err)
+		return 0
+	}
+	return count
+}
+
+// checkDivergence compares HEAD with the addon's remote commit and records how many commits
+// each side has that the other doesn't
+func (am *AddonManager) checkDivergence(ctx context.Context, addon *Addon) {
+	addon.Ahead, addon.Behind, addon.Diverged = 0, 0, false
+	if addon.RemoteCommit == "" || addon.LocalCommit == addon.RemoteCommit {
+		return
+	}
+
+	ahead, behind, err := am.git().AheadBehind(ctx, addon.Path, addon.RemoteCommit)
+	if err != nil {
+		debug.Printf("Failed to compare %s with its remote: %v", addon.Name, err)
+		return
+	}
+	addon.Ahead, addon.Behind = ahead, behind
+
+	// Pinned tags and commits are checked out exactly, so local commits don't matter there
+	addon.Diverged = addon.Ahead > 0 && addon.Behind > 0 && addon.PinnedKind != PinTag && addon.PinnedKind != PinCommit
+}
+
+// updateGitAddon updates a git addon using the given strategy and returns a description of
+// anything beyond a plain update. The commit it was on is recorded for rollback.
+func (am *AddonManager) updateGitAddon(ctx context.Context, addon *Addon, strategy string) (string, error) {
+	if addon.PinnedKind == PinTag || addon.PinnedKind == PinCommit {
+		return "", fmt.Errorf("addon is pinned to %s %s", addon.PinnedKind, addon.PinnedRef)
+	}
+
+	// Refresh the state, the scan may be stale by now
+	addon.LocalCommit = am.getLocalCommit(addon.Path)
+	addon.RemoteCommit = am.getRemoteCommit(ctx, addon)
+	addon.LocalChanges = am.countLocalChanges(ctx, addon.Path)
+	am.checkDivergence(ctx, addon)
+
+	if addon.HasLocalState() {
+		switch strategy {
+		case StrategySkip:
+			return "", fmt.Errorf("%w: %s", ErrSkipped, addon.describeLocalState())
+		case StrategyPull:
+			return "", fmt.Errorf("%s; choose stash or reset to update", addon.describeLocalState())
+		}
+	}
+
+	before := addon.LocalCommit
+	var detail string
+
+	if strategy == StrategyReset && (addon.HasLocalState() || addon.Ahead > 0) {
+		if addon.RemoteCommit == "" {
+			return "", fmt.Errorf("could not determine the remote commit to reset to")
+		}
+		if err := am.git().ResetHard(ctx, addon.Path, addon.RemoteCommit); err != nil {
+			return "", err
+		}
+		var discarded []string
+		if addon.LocalChanges > 0 {
+			discarded = append(discarded, fmt.Sprintf("%d modified files", addon.LocalChanges))
+		}
+		if addon.Ahead > 0 {
+			discarded = append(discarded, fmt.Sprintf("%d local commits", addon.Ahead))
+		}
+		detail = fmt.Sprintf("reset to %s, discarding %s", shortCommit(addon.RemoteCommit), strings.Join(discarded, " and "))
+	} else {
+		if addon.Diverged {
+			return "", fmt.Errorf("%s; stashing can't fix a diverged history, reset to update", addon.describeLocalState())
+		}
+
+		stashed := false
+		if addon.LocalChanges > 0 {
+			message := fmt.Sprintf("TurtleSilicon: before update %s", time.Now().Format("2006-01-02 15:04"))
+			if err := am.git().Stash(ctx, addon.Path, message); err != nil {
+				return "", fmt.Errorf("failed to stash local changes: %v", err)
+			}
+			stashed = true
+		}
+
+		if err := am.runGitPull(ctx, addon.Path); err != nil {
+			if stashed {
+				am.restoreStash(addon)
+			}
+			return "", err
+		}
+
+		if stashed {
+			if err := am.git().StashPop(ctx, addon.Path); err != nil {
+				debug.Printf("Failed to re-apply local changes of %s: %v", addon.Name, err)
+				detail = fmt.Sprintf("updated, but %d local changes conflict with it and were kept in the stash", addon.LocalChanges)
+			} else {
+				detail = fmt.Sprintf("updated and re-applied %d local changes", addon.LocalChanges)
+			}
+		}
+	}
+
+	after := am.getLocalCommit(addon.Path)
+	if before != "" && before != after {
+		am.recordPreviousCommit(addon, before)
+	}
+	addon.LocalCommit = after
+	addon.LocalChanges = am.countLocalChanges(ctx, addon.Path)
+	addon.Ahead, addon.Behind, addon.Diverged = 0, 0, false
+	return detail, nil
+}
+
+// restoreStash re-applies stashed changes after a failed update
+func (am *AddonManager) restoreStash(addon *Addon) {
+	if err := am.git().StashPop(context.Background(), addon.Path); err != nil {
+		debug.Printf("Warning: local changes of %s remain in the stash: %v", addon.Name, err)
+	}
+}
@@ -0,0 +1,500 @@
+package addons
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/version"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Reasons a SavedVariables snapshot was taken, stored in its file name
+const (
+	SnapshotManual        = "manual"
+	SnapshotScheduled     = "scheduled"
+	SnapshotBeforeUpdate  = "before-update"
+	SnapshotBeforeRestore = "before-restore"
+	SnapshotBeforeCleanup = "before-cleanup"
+)
+
+const (
+	maxSavedVariablesSnapshots = 20
+	snapshotTimeFormat         = "20060102-150405"
+)
+
+// Snapshot is a zip archive of every SavedVariables file of one game version
+type Snapshot struct {
+	Path    string
+	Created time.Time
+	Reason  string
+}
+
+// String describes the snapshot for lists
+func (s Snapshot) String() string {
+	reasons := map[string]string{
+		SnapshotManual:        "manual",
+		SnapshotScheduled:     "scheduled",
+		SnapshotBeforeUpdate:  "before addon update",
+		SnapshotBeforeRestore: "before restore",
+		SnapshotBeforeCleanup: "before cleanup",
+	}
+	reason, ok := reasons[s.Reason]
+	if !ok {
+		reason = s.Reason
+	}
+	return fmt.Sprintf("%s (%s)", s.Created.Format("2006-01-02 15:04:05"), reason)
+}
+
+// OrphanedSavedVariables groups the SavedVariables files of an addon that is no longer installed
+type OrphanedSavedVariables struct {
+	Addon string
+	Files []string
+}
+
+// savedVariablesSnapshotDir returns where snapshots of a game version are kept
+func savedVariablesSnapshotDir(gameVersion *version.GameVersion) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	id := "legacy"
+	if gameVersion != nil {
+		id = gameVersion.ID
+	}
+	return filepath.Join(dir, "TurtleSilicon", "SavedVariables", id), nil
+}
+
+// wtfAccountPath returns the game's WTF/Account directory, or an empty string if it doesn't exist
+func wtfAccountPath(gamePath string) string {
+	wtfPath := findChildFold(gamePath, "WTF")
+	if wtfPath == "" {
+		return ""
+	}
+	return findChildFold(wtfPath, "Account")
+}
+
+// findSavedVariablesFiles returns the account and character SavedVariables files, relative to
+// WTF/Account
+func findSavedVariablesFiles(accountPath string) []string {
+	var files []string
+	for _, pattern := range []string{
+		filepath.Join(accountPath, "*", "SavedVariables", "*.lua"),
+		filepath.Join(accountPath, "*", "*", "*", "SavedVariables", "*.lua"),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		for _, match := range matches {
+			if rel, err := filepath.Rel(accountPath, match); err == nil {
+				files = append(files, rel)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// SnapshotSavedVariables archives every SavedVariables file of the managed game version. It
+// returns nil without an error if there is nothing to back up yet.
+func (am *AddonManager) SnapshotSavedVariables(reason string) (*Snapshot, error) {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return nil, err
+	}
+	snapshotDir, err := savedVariablesSnapshotDir(am.gameVersion)
+	if err != nil {
+		return nil, err
+	}
+	return snapshotSavedVariables(gamePath, snapshotDir, reason)
+}
+
+func snapshotSavedVariables(gamePath, snapshotDir, reason string) (*Snapshot, error) {
+	accountPath := wtfAccountPath(gamePath)
+	if accountPath == "" {
+		return nil, nil
+	}
+	files := findSavedVariablesFiles(accountPath)
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+
+	created := time.Now()
+	snapshotPath := filepath.Join(snapshotDir, fmt.Sprintf("%s-%s.zip", created.Format(snapshotTimeFormat), reason))
+	for i := 2; utils.PathExists(snapshotPath); i++ {
+		snapshotPath = filepath.Join(snapshotDir, fmt.Sprintf("%s-%s-%d.zip", created.Format(snapshotTimeFormat), reason, i))
+	}
+
+	if err := writeSnapshot(snapshotPath, accountPath, files); err != nil {
+		os.Remove(snapshotPath)
+		return nil, fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	debug.Printf("Saved %d SavedVariables files to %s", len(files), snapshotPath)
+
+	pruneSnapshots(snapshotDir, maxSavedVariablesSnapshots)
+	return &Snapshot{Path: snapshotPath, Created: created, Reason: reason}, nil
+}
+
+func writeSnapshot(snapshotPath, accountPath string, files []string) error {
+	out, err := os.Create(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	archive := zip.NewWriter(out)
+	for _, rel := range files {
+		writer, err := archive.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(filepath.Join(accountPath, rel))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// ListSnapshots returns the SavedVariables snapshots of the managed game version, newest first
+func (am *AddonManager) ListSnapshots() []Snapshot {
+	snapshotDir, err := savedVariablesSnapshotDir(am.gameVersion)
+	if err != nil {
+		return nil
+	}
+	return listSnapshots(snapshotDir)
+}
+
+func listSnapshots(snapshotDir string) []Snapshot {
+	matches, _ := filepath.Glob(filepath.Join(snapshotDir, "*.zip"))
+
+	var snapshots []Snapshot
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), ".zip")
+		if len(name) < len(snapshotTimeFormat)+2 {
+			continue
+		}
+		created, err := time.ParseInLocation(snapshotTimeFormat, name[:len(snapshotTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		reason := name[len(snapshotTimeFormat)+1:]
+		// Strip the counter added for snapshots taken within the same second
+		if i := strings.LastIndex(reason, "-"); i > 0 && strings.Trim(reason[i+1:], "0123456789") == "" {
+			reason = reason[:i]
+		}
+		snapshots = append(snapshots, Snapshot{Path: match, Created: created, Reason: reason})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Created.Equal(snapshots[j].Created) {
+			return snapshots[i].Path > snapshots[j].Path
+		}
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+	return snapshots
+}
+
+// pruneSnapshots deletes all but the newest keep snapshots
+func pruneSnapshots(snapshotDir string, keep int) {
+	snapshots := listSnapshots(snapshotDir)
+	for i := keep; i < len(snapshots); i++ {
+		if err := os.Remove(snapshots[i].Path); err != nil {
+			debug.Printf("Failed to remove old snapshot %s: %v", snapshots[i].Path, err)
+		}
+	}
+}
+
+// RestoreSnapshot writes the files of a snapshot back into WTF/Account. The current state is
+// snapshotted first so the restore can be undone. Files that didn't exist when the snapshot
+// was taken are left alone.
+func (am *AddonManager) RestoreSnapshot(snapshot Snapshot) error {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return err
+	}
+	accountPath := wtfAccountPath(gamePath)
+	if accountPath == "" {
+		accountPath = filepath.Join(gamePath, "WTF", "Account")
+	}
+
+	// Open the snapshot first, the backup below may prune it if it is the oldest one
+	reader, err := zip.OpenReader(snapshot.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := am.SnapshotSavedVariables(SnapshotBeforeRestore); err != nil {
+		return fmt.Errorf("failed to back up current SavedVariables: %v", err)
+	}
+
+	if err := extractZipRoot(reader.File, ".", accountPath); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %v", err)
+	}
+	debug.Printf("Restored SavedVariables from %s", snapshot.Path)
+	return nil
+}
+
+// SnapshotSavedVariablesIfDue takes a scheduled snapshot for a game version if the interval
+// set in the preferences has passed since its last snapshot
+func SnapshotSavedVariablesIfDue(gameVersion *version.GameVersion) {
+	prefs, err := utils.LoadPrefs()
+	if err != nil || prefs.SavedVariablesSnapshotHours <= 0 || gameVersion == nil || gameVersion.GamePath == "" {
+		return
+	}
+
+	snapshotDir, err := savedVariablesSnapshotDir(gameVersion)
+	if err != nil {
+		return
+	}
+	if snapshots := listSnapshots(snapshotDir); len(snapshots) > 0 &&
+		time.Since(snapshots[0].Created) < time.Duration(prefs.SavedVariablesSnapshotHours)*time.Hour {
+		return
+	}
+
+	if _, err := snapshotSavedVariables(gameVersion.GamePath, snapshotDir, SnapshotScheduled); err != nil {
+		debug.Printf("Scheduled SavedVariables snapshot failed: %v", err)
+	}
+}
+
+// FindOrphanedSavedVariables returns SavedVariables files of addons that aren't installed
+func (am *AddonManager) FindOrphanedSavedVariables() []OrphanedSavedVariables {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return nil
+	}
+	accountPath := wtfAccountPath(gamePath)
+	if accountPath == "" {
+		return nil
+	}
+
+	installed := make(map[string]bool)
+	for _, addon := range am.addons {
+		installed[strings.ToLower(addon.Name)] = true
+	}
+
+	byAddon := make(map[string]*OrphanedSavedVariables)
+	var orphans []*OrphanedSavedVariables
+	for _, rel := range findSavedVariablesFiles(accountPath) {
+		name := strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+		if installed[strings.ToLower(name)] || isBlizzardAddon(name) {
+			continue
+		}
+		orphan := byAddon[strings.ToLower(name)]
+		if orphan == nil {
+			orphan = &OrphanedSavedVariables{Addon: name}
+			byAddon[strings.ToLower(name)] = orphan
+			orphans = append(orphans, orphan)
+		}
+		orphan.Files = append(orphan.Files, filepath.Join(accountPath, rel))
+	}
+
+	result := make([]OrphanedSavedVariables, len(orphans))
+	for i, orphan := range orphans {
+		result[i] = *orphan
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Addon) < strings.ToLower(result[j].Addon)
+	})
+	return result
+}
+
+// CleanupOrphanedSavedVariables deletes the given orphaned files and the backups the client
+// keeps next to them, after taking a snapshot
+func (am *AddonManager) CleanupOrphanedSavedVariables(orphans []OrphanedSavedVariables) (int, error) {
+	if _, err := am.SnapshotSavedVariables(SnapshotBeforeCleanup); err != nil {
+		return 0, fmt.Errorf("failed to back up SavedVariables: %v", err)
+	}
+
+	removed := 0
+	for _, orphan := range orphans {
+		for _, file := range orphan.Files {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return removed, fmt.Errorf("failed to remove %s: %v", file, err)
+			}
+			os.Remove(file + ".bak")
+			removed++
+		}
+	}
+	debug.Printf("Removed %d orphaned SavedVariables files", removed)
+	return removed, nil
+}
+
+// snapshotBeforeUpdate backs up SavedVariables before addons are changed. A failed snapshot
+// is logged but doesn't stop the update.
+func (am *AddonManager) snapshotBeforeUpdate() {
+	if _, err := am.SnapshotSavedVariables(SnapshotBeforeUpdate); err != nil {
+		debug.Printf("Warning: SavedVariables snapshot before update failed: %v", err)
+	}
+}
+
+// showSavedVariablesPopup lets the user take, schedule and restore SavedVariables snapshots and
+// clean up settings left behind by removed addons
+func (am *AddonManager) showSavedVariablesPopup() {
+	snapshots := am.ListSnapshots()
+	selected := -1
+
+	snapshotList := widget.NewList(
+		func() int { return len(snapshots) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(snapshots[id].String())
+		},
+	)
+
+	restoreButton := widget.NewButton("Restore Selected", nil)
+	restoreButton.Importance = widget.WarningImportance
+	restoreButton.Disable()
+
+	snapshotList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		restoreButton.Enable()
+	}
+
+	reloadSnapshots := func() {
+		snapshots = am.ListSnapshots()
+		selected = -1
+		snapshotList.UnselectAll()
+		snapshotList.Refresh()
+		restoreButton.Disable()
+	}
+
+	snapshotButton := widget.NewButton("Snapshot Now", func() {
+		go func() {
+			snapshot, err := am.SnapshotSavedVariables(SnapshotManual)
+			fyne.Do(func() {
+				switch {
+				case err != nil:
+					dialog.ShowError(err, am.window)
+				case snapshot == nil:
+					dialog.ShowInformation("Nothing to Back Up", "No SavedVariables files were found. They are created when you log out of the game.", am.window)
+				default:
+					reloadSnapshots()
+				}
+			})
+		}()
+	})
+	snapshotButton.Importance = widget.HighImportance
+
+	restoreButton.OnTapped = func() {
+		if selected < 0 || selected >= len(snapshots) {
+			return
+		}
+		snapshot := snapshots[selected]
+		message := fmt.Sprintf("Restore the SavedVariables from %s?\n\nThe current files are backed up first. Close the game before restoring, it overwrites these files when you log out.", snapshot.String())
+		dialog.ShowConfirm("Restore Snapshot", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			go func() {
+				err := am.RestoreSnapshot(snapshot)
+				fyne.Do(func() {
+					if err != nil {
+						dialog.ShowError(err, am.window)
+						return
+					}
+					reloadSnapshots()
+					dialog.ShowInformation("Restored", "SavedVariables were restored.", am.window)
+				})
+			}()
+		}, am.window)
+	}
+
+	scheduleOptions := []string{"Off", "Daily", "Weekly"}
+	scheduleHours := map[string]int{"Off": 0, "Daily": 24, "Weekly": 24 * 7}
+	scheduleSelect := widget.NewSelect(scheduleOptions, nil)
+	scheduleSelect.SetSelected("Off")
+	if prefs, err := utils.LoadPrefs(); err == nil {
+		for option, hours := range scheduleHours {
+			if hours == prefs.SavedVariablesSnapshotHours {
+				scheduleSelect.SetSelected(option)
+			}
+		}
+	}
+	scheduleSelect.OnChanged = func(selected string) {
+		prefs, err := utils.LoadPrefs()
+		if err != nil {
+			return
+		}
+		prefs.SavedVariablesSnapshotHours = scheduleHours[selected]
+		if err := utils.SavePrefs(prefs); err != nil {
+			debug.Printf("Failed to save snapshot schedule: %v", err)
+		}
+	}
+
+	orphans := am.FindOrphanedSavedVariables()
+	orphanFiles := 0
+	var orphanNames []string
+	for _, orphan := range orphans {
+		orphanFiles += len(orphan.Files)
+		orphanNames = append(orphanNames, orphan.Addon)
+	}
+
+	orphanLabel := widget.NewLabel("No SavedVariables of removed addons found.")
+	orphanLabel.Wrapping = fyne.TextWrapWord
+	cleanupButton := widget.NewButton("Clean Up...", nil)
+	cleanupButton.Disable()
+	if len(orphans) > 0 {
+		orphanLabel.SetText(fmt.Sprintf("%d SavedVariables files belong to %d addons that are no longer installed: %s", orphanFiles, len(orphans), strings.Join(orphanNames, ", ")))
+		cleanupButton.Enable()
+	}
+	cleanupButton.OnTapped = func() {
+		message := fmt.Sprintf("Delete %d SavedVariables files of %d removed addons?\n\nA snapshot is taken first, so they can be restored later.", orphanFiles, len(orphans))
+		dialog.ShowConfirm("Clean Up SavedVariables", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			go func() {
+				removed, err := am.CleanupOrphanedSavedVariables(orphans)
+				fyne.Do(func() {
+					reloadSnapshots()
+					if err != nil {
+						dialog.ShowError(err, am.window)
+						return
+					}
+					orphanLabel.SetText(fmt.Sprintf("Removed %d files.", removed))
+					cleanupButton.Disable()
+				})
+			}()
+		}, am.window)
+	}
+
+	infoLabel := widget.NewLabel(fmt.Sprintf("Snapshots archive every addon's settings. One is taken automatically before addons are updated, the last %d are kept.", maxSavedVariablesSnapshots))
+	infoLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		container.NewVBox(
+			infoLabel,
+			container.NewHBox(snapshotButton, restoreButton, widget.NewLabel("Automatic snapshots:"), scheduleSelect),
+			widget.NewSeparator(),
+		),
+		container.NewVBox(widget.NewSeparator(), orphanLabel, container.NewHBox(cleanupButton)),
+		nil,
+		nil,
+		snapshotList,
+	)
+
+	savedVariablesDialog := dialog.NewCustom("SavedVariables", "Close", content, am.window)
+	windowSize := am.window.Content().Size()
+	savedVariablesDialog.Resize(fyne.NewSize(windowSize.Width*3/4, windowSize.Height*3/4))
+	savedVariablesDialog.Show()
+}
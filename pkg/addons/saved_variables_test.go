@@ -0,0 +1,46 @@
+package addons
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotSavedVariables(t *testing.T) {
+	gamePath := t.TempDir()
+	snapshotDir := t.TempDir()
+
+	accountFile := filepath.Join(gamePath, "WTF", "Account", "ACCOUNT", "SavedVariables", "pfUI.lua")
+	characterFile := filepath.Join(gamePath, "WTF", "Account", "ACCOUNT", "Nordanaar", "Hero", "SavedVariables", "pfUI.lua")
+	for _, file := range []string{accountFile, characterFile} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("pfUI_config = {}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot, err := snapshotSavedVariables(gamePath, snapshotDir, SnapshotBeforeUpdate)
+	if err != nil || snapshot == nil {
+		t.Fatalf("snapshotSavedVariables() = %v, %v", snapshot, err)
+	}
+	if _, err := snapshotSavedVariables(gamePath, snapshotDir, SnapshotBeforeUpdate); err != nil {
+		t.Fatalf("second snapshotSavedVariables() error = %v", err)
+	}
+
+	snapshots := listSnapshots(snapshotDir)
+	if len(snapshots) != 2 {
+		t.Fatalf("listSnapshots() returned %d snapshots, want 2", len(snapshots))
+	}
+	for _, s := range snapshots {
+		if s.Reason != SnapshotBeforeUpdate {
+			t.Errorf("snapshot %s has reason %q, want %q", s.Path, s.Reason, SnapshotBeforeUpdate)
+		}
+	}
+
+	pruneSnapshots(snapshotDir, 1)
+	if remaining := listSnapshots(snapshotDir); len(remaining) != 1 || remaining[0].Path != snapshots[0].Path {
+		t.Errorf("pruneSnapshots() kept %v, want only the newest", remaining)
+	}
+}
@@ -0,0 +1,262 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	defaultAddonConcurrency = 4
+	defaultAddonTimeout     = 2 * time.Minute
+)
+
+// TaskResult is the outcome of one addon operation run by the worker pool
+type TaskResult struct {
+	Name     string
+	Detail   string
+	Err      error
+	Duration time.Duration
+}
+
+// String returns a one-line summary of the result
+func (r TaskResult) String() string {
+	switch {
+	case r.Err == nil && r.Detail != "":
+		return fmt.Sprintf("✓ %s (%.1fs): %s", r.Name, r.Duration.Seconds(), r.Detail)
+	case r.Err == nil:
+		return fmt.Sprintf("✓ %s (%.1fs)", r.Name, r.Duration.Seconds())
+	case errors.Is(r.Err, ErrSkipped):
+		return fmt.Sprintf("– %s: %v", r.Name, r.Err)
+	case errors.Is(r.Err, context.Canceled):
+		return fmt.Sprintf("– %s: cancelled", r.Name)
+	case errors.Is(r.Err, context.DeadlineExceeded):
+		return fmt.Sprintf("✗ %s: timed out", r.Name)
+	default:
+		return fmt.Sprintf("✗ %s: %v", r.Name, r.Err)
+	}
+}
+
+// addonPoolSettings returns the configured number of parallel addon operations and the
+// timeout for each one
+func addonPoolSettings() (int, time.Duration) {
+	concurrency, timeout := defaultAddonConcurrency, defaultAddonTimeout
+
+	prefs, _ := utils.LoadPrefs()
+	if prefs != nil {
+		if prefs.AddonConcurrency > 0 {
+			concurrency = prefs.AddonConcurrency
+		}
+		if prefs.AddonTimeoutSeconds > 0 {
+			timeout = time.Duration(prefs.AddonTimeoutSeconds) * time.Second
+		}
+	}
+	return concurrency, timeout
+}
+
+// runTasks runs fn for every name on at most concurrency workers. Each call gets its own
+// timeout, and cancelling ctx aborts running tasks and skips those not yet started. The
+// string fn returns is kept as the result's detail. onStart and onDone are called from the
+// worker goroutines and may be nil.
+func runTasks(ctx context.Context, names []string, concurrency int, timeout time.Duration,
+	fn func(ctx context.Context, i int) (string, error), onStart func(i int), onDone func(i int, result TaskResult)) []TaskResult {
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]TaskResult, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency && w < len(names); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := TaskResult{Name: names[i]}
+
+				if err := ctx.Err(); err != nil {
+					result.Err = err
+				} else {
+					if onStart != nil {
+						onStart(i)
+					}
+					taskCtx, cancel := context.WithTimeout(ctx, timeout)
+					start := time.Now()
+					result.Detail, result.Err = fn(taskCtx, i)
+					result.Duration = time.Since(start)
+					// Report the timeout rather than the "signal: killed" it causes
+					if taskErr := taskCtx.Err(); result.Err != nil && taskErr != nil {
+						result.Err = taskErr
+					}
+					cancel()
+				}
+
+				if result.Err != nil {
+					debug.Printf("Task %s failed: %v", result.Name, result.Err)
+				}
+				results[i] = result
+				if onDone != nil {
+					onDone(i, result)
+				}
+			}
+		}()
+	}
+
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// taskProgress is a modal popup showing the live status of every task in a worker pool run
+type taskProgress struct {
+	popup       *widget.PopUp
+	progressBar *widget.ProgressBar
+	statusLabel *widget.Label
+	list        *widget.List
+	statuses    []string
+	done        int
+}
+
+// newTaskProgress creates the progress popup. Cancel is called when the user presses Cancel.
+func (am *AddonManager) newTaskProgress(title string, names []string, cancel func()) *taskProgress {
+	tp := &taskProgress{statuses: make([]string, len(names))}
+	for i, name := range names {
+		tp.statuses[i] = "… " + name
+	}
+
+	titleLabel := widget.NewLabel(title)
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	tp.statusLabel = widget.NewLabel(fmt.Sprintf("0/%d done", len(names)))
+	tp.progressBar = widget.NewProgressBar()
+	tp.progressBar.Max = float64(len(names))
+
+	tp.list = widget.NewList(
+		func() int { return len(tp.statuses) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(tp.statuses[id])
+		},
+	)
+
+	cancelButton := widget.NewButton("Cancel", nil)
+	cancelButton.Importance = widget.WarningImportance
+	cancelButton.OnTapped = func() {
+		cancelButton.Disable()
+		tp.statusLabel.SetText("Cancelling...")
+		cancel()
+	}
+
+	content := container.NewBorder(
+		container.NewVBox(titleLabel, widget.NewSeparator(), tp.statusLabel, tp.progressBar),
+		container.NewCenter(cancelButton),
+		nil,
+		nil,
+		tp.list,
+	)
+
+	tp.popup = widget.NewModalPopUp(container.NewPadded(content), am.window.Canvas())
+	windowSize := am.window.Content().Size()
+	tp.popup.Resize(fyne.NewSize(windowSize.Width*2/3, windowSize.Height*2/3))
+	tp.popup.Show()
+	return tp
+}
+
+// started marks a task as running. Safe to call from worker goroutines.
+func (tp *taskProgress) started(i int) {
+	fyne.Do(func() {
+		tp.statuses[i] = "⟳ " + strings.TrimPrefix(tp.statuses[i], "… ")
+		tp.list.RefreshItem(i)
+	})
+}
+
+// finished records a task's result. Safe to call from worker goroutines.
+func (tp *taskProgress) finished(i int, result TaskResult) {
+	fyne.Do(func() {
+		tp.done++
+		tp.statuses[i] = result.String()
+		tp.list.RefreshItem(i)
+		tp.progressBar.SetValue(float64(tp.done))
+		tp.statusLabel.SetText(fmt.Sprintf("%d/%d done", tp.done, len(tp.statuses)))
+	})
+}
+
+func (tp *taskProgress) hide() {
+	tp.popup.Hide()
+}
+
+// showTaskResults summarizes a worker pool run with one line per addon
+func (am *AddonManager) showTaskResults(title string, results []TaskResult) {
+	succeeded, failed, skipped, cancelled := 0, 0, 0, 0
+	var lines []string
+	for _, result := range results {
+		switch {
+		case result.Err == nil:
+			succeeded++
+		case errors.Is(result.Err, ErrSkipped):
+			skipped++
+		case errors.Is(result.Err, context.Canceled):
+			cancelled++
+		default:
+			failed++
+		}
+		lines = append(lines, result.String())
+	}
+
+	summary := fmt.Sprintf("Succeeded: %d   Failed: %d", succeeded, failed)
+	if skipped > 0 {
+		summary += fmt.Sprintf("   Skipped: %d", skipped)
+	}
+	if cancelled > 0 {
+		summary += fmt.Sprintf("   Cancelled: %d", cancelled)
+	}
+	summaryLabel := widget.NewLabel(summary)
+	summaryLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	resultsLabel := widget.NewLabel(strings.Join(lines, "\n"))
+	resultsLabel.Wrapping = fyne.TextWrapWord
+
+	resultsDialog := dialog.NewCustom(title, "Close", container.NewBorder(summaryLabel, nil, nil, nil, container.NewScroll(resultsLabel)), am.window)
+	windowSize := am.window.Content().Size()
+	resultsDialog.Resize(fyne.NewSize(windowSize.Width*3/4, windowSize.Height*2/3))
+	resultsDialog.Show()
+}
+
+var (
+	addonLocksMutex sync.Mutex
+	addonLocks      = make(map[string]*sync.Mutex)
+)
+
+// lockAddonPath locks an addon folder for an operation that changes it and returns the
+// function that unlocks it
+func lockAddonPath(addonPath string) func() {
+	key := filepath.Clean(addonPath)
+	addonLocksMutex.Lock()
+	lock := addonLocks[key]
+	if lock == nil {
+		lock = &sync.Mutex{}
+		addonLocks[key] = lock
+	}
+	addonLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
@@ -0,0 +1,63 @@
+package addons
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunTasksBoundsConcurrency(t *testing.T) {
+	names := make([]string, 20)
+	for i := range names {
+		names[i] = "addon"
+	}
+
+	var running, peak int32
+	results := runTasks(context.Background(), names, 3, time.Second, func(ctx context.Context, i int) (string, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return "", nil
+	}, nil, nil)
+
+	if peak > 3 {
+		t.Errorf("ran %d tasks at once, want at most 3", peak)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("unexpected error: %v", result.Err)
+		}
+	}
+}
+
+func TestRunTasksTimeoutAndCancel(t *testing.T) {
+	results := runTasks(context.Background(), []string{"slow"}, 1, 10*time.Millisecond, func(ctx context.Context, i int) (string, error) {
+		<-ctx.Done()
+		return "", errors.New("killed")
+	}, nil, nil)
+	if !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("timed out task error = %v, want deadline exceeded", results[0].Err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results = runTasks(ctx, []string{"first", "second", "third"}, 1, time.Second, func(ctx context.Context, i int) (string, error) {
+		cancel()
+		return "", nil
+	}, nil, nil)
+	if results[0].Err != nil {
+		t.Errorf("first task error = %v, want nil", results[0].Err)
+	}
+	for _, result := range results[1:] {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("%s error = %v, want cancelled", result.Name, result.Err)
+		}
+	}
+}
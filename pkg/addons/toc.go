@@ -0,0 +1,130 @@
+package addons
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TocInfo holds the metadata declared in an addon's .toc file
+type TocInfo struct {
+	Title                      string
+	Notes                      string
+	Author                     string
+	Version                    string
+	Interface                  string
+	Dependencies               []string
+	OptionalDeps               []string
+	SavedVariables             []string
+	SavedVariablesPerCharacter []string
+}
+
+// colorCodePattern matches the |cAARRGGBB and |r escapes used to color addon titles
+var colorCodePattern = regexp.MustCompile(`(?i)\|c[0-9a-f]{8}|\|r`)
+
+// interfaceVersions maps a client version to the ## Interface number its addons declare
+var interfaceVersions = map[string]string{
+	"1.12.1": "11200",
+	"2.4.3":  "20400",
+	"3.3.5a": "30300",
+}
+
+// interfaceVersionFor returns the expected ## Interface number for a WoW client version
+func interfaceVersionFor(wowVersion string) string {
+	return interfaceVersions[wowVersion]
+}
+
+// stripColorCodes removes WoW color escapes from a string
+func stripColorCodes(s string) string {
+	return strings.TrimSpace(colorCodePattern.ReplaceAllString(s, ""))
+}
+
+// findTocFile returns the .toc file the client would load for an addon folder, preferring
+// <Folder>.toc and falling back to the first .toc found
+func findTocFile(addonPath string) string {
+	preferred := filepath.Join(addonPath, filepath.Base(addonPath)+".toc")
+	if _, err := os.Stat(preferred); err == nil {
+		return preferred
+	}
+
+	tocFiles, _ := filepath.Glob(filepath.Join(addonPath, "*.toc"))
+	if len(tocFiles) > 0 {
+		return tocFiles[0]
+	}
+	return ""
+}
+
+// parseTocFile reads and parses a .toc file
+func parseTocFile(tocPath string) (*TocInfo, error) {
+	content, err := os.ReadFile(tocPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseToc(string(content)), nil
+}
+
+// parseToc parses the ## metadata lines of a .toc file
+func parseToc(content string) *TocInfo {
+	info := &TocInfo{}
+
+	content = strings.TrimPrefix(content, "\ufeff")
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "##") {
+			continue
+		}
+
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "##"), ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(key) {
+		case "title":
+			info.Title = stripColorCodes(value)
+		case "notes":
+			info.Notes = stripColorCodes(value)
+		case "author":
+			info.Author = stripColorCodes(value)
+		case "version":
+			info.Version = value
+		case "interface":
+			info.Interface = value
+		case "optionaldeps":
+			info.OptionalDeps = splitTocList(value)
+		case "savedvariables":
+			info.SavedVariables = splitTocList(value)
+		case "savedvariablespercharacter":
+			info.SavedVariablesPerCharacter = splitTocList(value)
+		default:
+			// The client treats any key starting with "Dep" (Dependencies, Dependancies,
+			// Dep) as well as RequiredDeps as hard dependencies
+			lowerKey := strings.ToLower(key)
+			if strings.HasPrefix(lowerKey, "dep") || lowerKey == "requireddeps" {
+				info.Dependencies = append(info.Dependencies, splitTocList(value)...)
+			}
+		}
+	}
+
+	return info
+}
+
+// splitTocList splits a comma separated .toc value into its trimmed entries
+func splitTocList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isBlizzardAddon reports whether name is one of Blizzard's own load-on-demand addons, which
+// are loaded from the game archives rather than Interface/AddOns
+func isBlizzardAddon(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), "blizzard_")
+}
@@ -0,0 +1,56 @@
+package addons
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseToc(t *testing.T) {
+	content := "\ufeff## Interface: 11200\r\n" +
+		"## Title: |cff33ffccpf|cffffffffQuest |r\r\n" +
+		"## Author: Shagu\r\n" +
+		"## Version: 5.0.0\r\n" +
+		"## Notes: Quest helper\r\n" +
+		"## Dependencies: pfUI, Ace2\r\n" +
+		"## RequiredDeps: LibStub\r\n" +
+		"## OptionalDeps: Cartographer\r\n" +
+		"## SavedVariables: pfQuest_config, pfQuest_history\r\n" +
+		"## SavedVariablesPerCharacter: pfQuest_char\r\n" +
+		"# Comment line\r\n" +
+		"init.lua\r\n"
+
+	toc := parseToc(content)
+
+	if toc.Title != "pfQuest" {
+		t.Errorf("Title = %q, want %q", toc.Title, "pfQuest")
+	}
+	if toc.Interface != "11200" || toc.Author != "Shagu" || toc.Version != "5.0.0" || toc.Notes != "Quest helper" {
+		t.Errorf("unexpected metadata: %+v", toc)
+	}
+	if want := []string{"pfUI", "Ace2", "LibStub"}; !reflect.DeepEqual(toc.Dependencies, want) {
+		t.Errorf("Dependencies = %v, want %v", toc.Dependencies, want)
+	}
+	if want := []string{"Cartographer"}; !reflect.DeepEqual(toc.OptionalDeps, want) {
+		t.Errorf("OptionalDeps = %v, want %v", toc.OptionalDeps, want)
+	}
+	if want := []string{"pfQuest_config", "pfQuest_history"}; !reflect.DeepEqual(toc.SavedVariables, want) {
+		t.Errorf("SavedVariables = %v, want %v", toc.SavedVariables, want)
+	}
+	if want := []string{"pfQuest_char"}; !reflect.DeepEqual(toc.SavedVariablesPerCharacter, want) {
+		t.Errorf("SavedVariablesPerCharacter = %v, want %v", toc.SavedVariablesPerCharacter, want)
+	}
+}
+
+func TestInterfaceVersionFor(t *testing.T) {
+	tests := map[string]string{
+		"1.12.1": "11200",
+		"2.4.3":  "20400",
+		"3.3.5a": "30300",
+		"4.3.4":  "",
+	}
+	for wowVersion, want := range tests {
+		if got := interfaceVersionFor(wowVersion); got != want {
+			t.Errorf("interfaceVersionFor(%q) = %q, want %q", wowVersion, got, want)
+		}
+	}
+}
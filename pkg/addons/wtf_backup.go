@@ -0,0 +1,644 @@
+package addons
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/version"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const maxWTFBackupsPerMachine = 10
+
+// WTFBackup is a zip archive of a game version's whole WTF folder
+type WTFBackup struct {
+	Path    string
+	Created time.Time
+	Machine string
+}
+
+// String describes the backup for lists
+func (b WTFBackup) String() string {
+	return fmt.Sprintf("%s (%s)", b.Created.Format("2006-01-02 15:04:05"), b.Machine)
+}
+
+// WTFDiff lists the files that differ between two states of a WTF folder
+type WTFDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty reports whether both states are identical
+func (d WTFDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// wtfBackupDir returns where WTF backups of a game version are kept: below the folder chosen
+// in the preferences, so it can be a synced cloud folder, or the config directory by default
+func wtfBackupDir(gameVersion *version.GameVersion) (string, error) {
+	base := ""
+	if prefs, err := utils.LoadPrefs(); err == nil {
+		base = prefs.WTFBackupDir
+	}
+	if base == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(dir, "TurtleSilicon", "WTF")
+	}
+
+	id := "legacy"
+	if gameVersion != nil {
+		id = gameVersion.ID
+	}
+	return filepath.Join(base, id), nil
+}
+
+// machineName identifies this Mac in backup names, so backups synced from several machines
+// can be told apart
+func machineName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+	host = strings.TrimSuffix(host, ".local")
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, host)
+}
+
+// wtfFiles returns every file below the WTF folder, relative to it
+func wtfFiles(wtfPath string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(wtfPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || entry.Name() == ".DS_Store" {
+			return nil
+		}
+		rel, err := filepath.Rel(wtfPath, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// BackupWTF archives the managed game version's WTF folder into the backup directory
+func (am *AddonManager) BackupWTF() (*WTFBackup, error) {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return nil, err
+	}
+	backupDir, err := wtfBackupDir(am.gameVersion)
+	if err != nil {
+		return nil, err
+	}
+	return backupWTF(gamePath, backupDir)
+}
+
+func backupWTF(gamePath, backupDir string) (*WTFBackup, error) {
+	wtfPath := findChildFold(gamePath, "WTF")
+	if wtfPath == "" {
+		return nil, fmt.Errorf("no WTF folder found in %s", gamePath)
+	}
+	files, err := wtfFiles(wtfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WTF folder: %v", err)
+	}
+
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	backup := &WTFBackup{Created: time.Now(), Machine: machineName()}
+	backup.Path = filepath.Join(backupDir, fmt.Sprintf("%s-%s.zip", backup.Created.Format(snapshotTimeFormat), backup.Machine))
+
+	// Write under a temporary name so sync clients never pick up a half-written archive
+	partPath := backup.Path + ".part"
+	if err := writeSnapshot(partPath, wtfPath, files); err != nil {
+		os.Remove(partPath)
+		return nil, fmt.Errorf("failed to write backup: %v", err)
+	}
+	if err := os.Rename(partPath, backup.Path); err != nil {
+		os.Remove(partPath)
+		return nil, fmt.Errorf("failed to write backup: %v", err)
+	}
+	debug.Printf("Backed up %d WTF files to %s", len(files), backup.Path)
+
+	pruneWTFBackups(backupDir, backup.Machine, maxWTFBackupsPerMachine)
+	return backup, nil
+}
+
+// ListWTFBackups returns the WTF backups of the managed game version from all machines, newest first
+func (am *AddonManager) ListWTFBackups() []WTFBackup {
+	backupDir, err := wtfBackupDir(am.gameVersion)
+	if err != nil {
+		return nil
+	}
+	return listWTFBackups(backupDir)
+}
+
+func listWTFBackups(backupDir string) []WTFBackup {
+	matches, _ := filepath.Glob(filepath.Join(backupDir, "*.zip"))
+
+	var backups []WTFBackup
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), ".zip")
+		if len(name) < len(snapshotTimeFormat)+2 {
+			continue
+		}
+		created, err := time.ParseInLocation(snapshotTimeFormat, name[:len(snapshotTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, WTFBackup{Path: match, Created: created, Machine: name[len(snapshotTimeFormat)+1:]})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+	return backups
+}
+
+// pruneWTFBackups deletes all but the newest keep backups made on machine. Backups of other
+// machines sharing the folder are left to those machines.
+func pruneWTFBackups(backupDir, machine string, keep int) {
+	kept := 0
+	for _, backup := range listWTFBackups(backupDir) {
+		if backup.Machine != machine {
+			continue
+		}
+		kept++
+		if kept <= keep {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			debug.Printf("Failed to remove old WTF backup %s: %v", backup.Path, err)
+		}
+	}
+}
+
+// RestoreWTF writes a backup back into the managed game version's WTF folder, backing up the
+// current folder first. Files that aren't in the backup are left alone.
+func (am *AddonManager) RestoreWTF(backup WTFBackup) error {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return err
+	}
+
+	reader, err := zip.OpenReader(backup.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %v", err)
+	}
+	defer reader.Close()
+
+	wtfPath := findChildFold(gamePath, "WTF")
+	if wtfPath == "" {
+		wtfPath = filepath.Join(gamePath, "WTF")
+	} else if _, err := am.BackupWTF(); err != nil {
+		return fmt.Errorf("failed to back up the current WTF folder: %v", err)
+	}
+
+	if err := extractZipRoot(reader.File, ".", wtfPath); err != nil {
+		return fmt.Errorf("failed to restore backup: %v", err)
+	}
+	debug.Printf("Restored WTF folder from %s", backup.Path)
+	return nil
+}
+
+// wtfBackupIndex maps each file in a backup to its checksum
+func wtfBackupIndex(backupPath string) (map[string]uint32, error) {
+	reader, err := zip.OpenReader(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	index := make(map[string]uint32)
+	for _, file := range reader.File {
+		if !file.FileInfo().IsDir() {
+			index[file.Name] = file.CRC32
+		}
+	}
+	return index, nil
+}
+
+// currentWTFIndex maps each file in the managed game version's WTF folder to its checksum,
+// using the same names as a backup
+func (am *AddonManager) currentWTFIndex() (map[string]uint32, error) {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]uint32)
+	wtfPath := findChildFold(gamePath, "WTF")
+	if wtfPath == "" {
+		return index, nil
+	}
+
+	files, err := wtfFiles(wtfPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range files {
+		content, err := os.ReadFile(filepath.Join(wtfPath, rel))
+		if err != nil {
+			return nil, err
+		}
+		index[filepath.ToSlash(rel)] = crc32.ChecksumIEEE(content)
+	}
+	return index, nil
+}
+
+// DiffWTF compares two backups. An empty path stands for the current WTF folder.
+func (am *AddonManager) DiffWTF(fromPath, toPath string) (WTFDiff, error) {
+	load := func(path string) (map[string]uint32, error) {
+		if path == "" {
+			return am.currentWTFIndex()
+		}
+		return wtfBackupIndex(path)
+	}
+
+	from, err := load(fromPath)
+	if err != nil {
+		return WTFDiff{}, fmt.Errorf("failed to read %s: %v", fromPath, err)
+	}
+	to, err := load(toPath)
+	if err != nil {
+		return WTFDiff{}, fmt.Errorf("failed to read %s: %v", toPath, err)
+	}
+	return diffWTFIndexes(from, to), nil
+}
+
+func diffWTFIndexes(from, to map[string]uint32) WTFDiff {
+	var diff WTFDiff
+	for name, sum := range to {
+		if oldSum, found := from[name]; !found {
+			diff.Added = append(diff.Added, name)
+		} else if oldSum != sum {
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for name := range from {
+		if _, found := to[name]; !found {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// WTFAccounts returns the account folders of the managed game version
+func (am *AddonManager) WTFAccounts() []string {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return nil
+	}
+	accountPath := wtfAccountPath(gamePath)
+	if accountPath == "" {
+		return nil
+	}
+	return subdirectories(accountPath)
+}
+
+// CopyAccountLayout copies an account's keybindings, macros, chat and UI layout, including its
+// realm and character folders, to another game version. AddOns.txt and SavedVariables are only
+// copied if includeAddonSettings is set, since the other version may run different addons.
+// The target's WTF folder is backed up first.
+func (am *AddonManager) CopyAccountLayout(account string, target *version.GameVersion, includeAddonSettings bool) (int, error) {
+	gamePath, err := am.gamePath()
+	if err != nil {
+		return 0, err
+	}
+	sourcePath := filepath.Join(wtfAccountPath(gamePath), account)
+	if wtfAccountPath(gamePath) == "" || !utils.DirExists(sourcePath) {
+		return 0, fmt.Errorf("account %s not found", account)
+	}
+	if target.GamePath == "" {
+		return 0, fmt.Errorf("no game folder set for %s", target.DisplayName)
+	}
+
+	targetAccounts := wtfAccountPath(target.GamePath)
+	if targetAccounts == "" {
+		targetAccounts = filepath.Join(target.GamePath, "WTF", "Account")
+	} else {
+		backupDir, err := wtfBackupDir(target)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := backupWTF(target.GamePath, backupDir); err != nil {
+			return 0, fmt.Errorf("failed to back up the WTF folder of %s: %v", target.DisplayName, err)
+		}
+	}
+	targetPath := filepath.Join(targetAccounts, account)
+
+	files, err := wtfFiles(sourcePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list account folder: %v", err)
+	}
+
+	copied := 0
+	for _, rel := range files {
+		if !includeAddonSettings && isAddonSettingsFile(rel) {
+			continue
+		}
+		destPath := filepath.Join(targetPath, rel)
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return copied, err
+		}
+		if err := utils.CopyFile(filepath.Join(sourcePath, rel), destPath); err != nil {
+			return copied, fmt.Errorf("failed to copy %s: %v", rel, err)
+		}
+		copied++
+	}
+
+	debug.Printf("Copied %d files of account %s to %s", copied, account, target.DisplayName)
+	return copied, nil
+}
+
+// isAddonSettingsFile reports whether a path below an account folder holds addon state
+// rather than the client's own layout
+func isAddonSettingsFile(rel string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if strings.EqualFold(part, "SavedVariables") {
+			return true
+		}
+	}
+	return strings.EqualFold(filepath.Base(rel), "AddOns.txt")
+}
+
+// showWTFBackupPopup manages backups of the WTF folder, compares them and copies an account's
+// layout to another game version
+func (am *AddonManager) showWTFBackupPopup() {
+	backups := am.ListWTFBackups()
+	selected := -1
+
+	backupList := widget.NewList(
+		func() int { return len(backups) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(backups[id].String())
+		},
+	)
+
+	restoreButton := widget.NewButton("Restore Selected", nil)
+	restoreButton.Importance = widget.WarningImportance
+	restoreButton.Disable()
+	backupList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		restoreButton.Enable()
+	}
+
+	locationLabel := widget.NewLabel("")
+	locationLabel.Truncation = fyne.TextTruncateEllipsis
+
+	reloadBackups := func() {
+		backups = am.ListWTFBackups()
+		selected = -1
+		backupList.UnselectAll()
+		backupList.Refresh()
+		restoreButton.Disable()
+		if dir, err := wtfBackupDir(am.gameVersion); err == nil {
+			locationLabel.SetText("Location: " + dir)
+		}
+	}
+	reloadBackups()
+
+	setBackupDir := func(dir string) {
+		prefs, err := utils.LoadPrefs()
+		if err != nil {
+			dialog.ShowError(err, am.window)
+			return
+		}
+		prefs.WTFBackupDir = dir
+		if err := utils.SavePrefs(prefs); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save backup location: %v", err), am.window)
+			return
+		}
+		reloadBackups()
+	}
+
+	changeLocationButton := widget.NewButton("Change...", func() {
+		folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, am.window)
+				return
+			}
+			if uri != nil {
+				setBackupDir(uri.Path())
+			}
+		}, am.window)
+		windowSize := am.window.Canvas().Size()
+		folderDialog.Resize(fyne.NewSize(windowSize.Width*5/6, windowSize.Height*5/6))
+		folderDialog.Show()
+	})
+	defaultLocationButton := widget.NewButton("Default", func() {
+		setBackupDir("")
+	})
+
+	backupButton := widget.NewButton("Back Up Now", func() {
+		go func() {
+			_, err := am.BackupWTF()
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, am.window)
+					return
+				}
+				reloadBackups()
+			})
+		}()
+	})
+	backupButton.Importance = widget.HighImportance
+
+	restoreButton.OnTapped = func() {
+		if selected < 0 || selected >= len(backups) {
+			return
+		}
+		backup := backups[selected]
+		message := fmt.Sprintf("Restore the WTF folder from %s?\n\nThe current folder is backed up first. Close the game before restoring.", backup.String())
+		dialog.ShowConfirm("Restore WTF Backup", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			go func() {
+				err := am.RestoreWTF(backup)
+				fyne.Do(func() {
+					if err != nil {
+						dialog.ShowError(err, am.window)
+						return
+					}
+					reloadBackups()
+					dialog.ShowInformation("Restored", "The WTF folder was restored.", am.window)
+				})
+			}()
+		}, am.window)
+	}
+
+	compareButton := widget.NewButton("Compare...", func() {
+		am.showWTFCompareDialog(backups)
+	})
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, container.NewHBox(changeLocationButton, defaultLocationButton), locationLabel),
+			container.NewHBox(backupButton, restoreButton, compareButton),
+			widget.NewSeparator(),
+		),
+		am.createCopyLayoutSection(),
+		nil,
+		nil,
+		backupList,
+	)
+
+	wtfDialog := dialog.NewCustom("WTF Backup", "Close", content, am.window)
+	windowSize := am.window.Content().Size()
+	wtfDialog.Resize(fyne.NewSize(windowSize.Width*3/4, windowSize.Height*3/4))
+	wtfDialog.Show()
+}
+
+// showWTFCompareDialog lets the user pick two backups, or the current folder, and lists the
+// files that differ between them
+func (am *AddonManager) showWTFCompareDialog(backups []WTFBackup) {
+	const currentOption = "Current WTF folder"
+	options := []string{currentOption}
+	for _, backup := range backups {
+		options = append(options, backup.String())
+	}
+	pathFor := func(option string) string {
+		for _, backup := range backups {
+			if backup.String() == option {
+				return backup.Path
+			}
+		}
+		return ""
+	}
+
+	fromSelect := widget.NewSelect(options, nil)
+	toSelect := widget.NewSelect(options, nil)
+	toSelect.SetSelected(currentOption)
+	if len(backups) > 0 {
+		fromSelect.SetSelected(options[1])
+	} else {
+		fromSelect.SetSelected(currentOption)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("From", fromSelect),
+		widget.NewFormItem("To", toSelect),
+	)
+
+	dialog.ShowCustomConfirm("Compare WTF Backups", "Compare", "Cancel", form, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		go func() {
+			diff, err := am.DiffWTF(pathFor(fromSelect.Selected), pathFor(toSelect.Selected))
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, am.window)
+					return
+				}
+				am.showWTFDiff(fromSelect.Selected, toSelect.Selected, diff)
+			})
+		}()
+	}, am.window)
+}
+
+func (am *AddonManager) showWTFDiff(from, to string, diff WTFDiff) {
+	var lines []string
+	for _, name := range diff.Added {
+		lines = append(lines, "+ "+name)
+	}
+	for _, name := range diff.Removed {
+		lines = append(lines, "- "+name)
+	}
+	for _, name := range diff.Changed {
+		lines = append(lines, "~ "+name)
+	}
+	if diff.Empty() {
+		lines = append(lines, "No differences.")
+	}
+
+	summaryLabel := widget.NewLabel(fmt.Sprintf("%s → %s\nAdded: %d   Removed: %d   Changed: %d", from, to, len(diff.Added), len(diff.Removed), len(diff.Changed)))
+	summaryLabel.TextStyle = fyne.TextStyle{Bold: true}
+	diffLabel := widget.NewLabel(strings.Join(lines, "\n"))
+
+	diffDialog := dialog.NewCustom("WTF Differences", "Close", container.NewBorder(summaryLabel, nil, nil, nil, container.NewScroll(diffLabel)), am.window)
+	windowSize := am.window.Content().Size()
+	diffDialog.Resize(fyne.NewSize(windowSize.Width*3/4, windowSize.Height*2/3))
+	diffDialog.Show()
+}
+
+// createCopyLayoutSection builds the controls for copying an account's layout to another version
+func (am *AddonManager) createCopyLayoutSection() fyne.CanvasObject {
+	accounts := am.WTFAccounts()
+	versions := am.otherGameVersions()
+	if len(accounts) == 0 || len(versions) == 0 {
+		return container.NewVBox(widget.NewSeparator(), widget.NewLabel("Copying a layout needs an account in this version's WTF folder and another version with a game folder."))
+	}
+
+	var versionNames []string
+	for _, ver := range versions {
+		versionNames = append(versionNames, ver.DisplayName)
+	}
+
+	accountSelect := widget.NewSelect(accounts, nil)
+	accountSelect.SetSelectedIndex(0)
+	targetSelect := widget.NewSelect(versionNames, nil)
+	targetSelect.SetSelectedIndex(0)
+	addonSettingsCheck := widget.NewCheck("Include AddOns.txt and SavedVariables", nil)
+
+	copyButton := widget.NewButton("Copy Layout", func() {
+		account := accountSelect.Selected
+		target := versions[targetSelect.SelectedIndex()]
+		includeAddonSettings := addonSettingsCheck.Checked
+
+		message := fmt.Sprintf("Copy the keybindings, macros and UI layout of %s to %s?\n\nExisting files there are overwritten, after backing up its WTF folder.", account, target.DisplayName)
+		dialog.ShowConfirm("Copy Layout", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			go func() {
+				copied, err := am.CopyAccountLayout(account, target, includeAddonSettings)
+				fyne.Do(func() {
+					if err != nil {
+						dialog.ShowError(err, am.window)
+						return
+					}
+					dialog.ShowInformation("Layout Copied", fmt.Sprintf("Copied %d files to %s.", copied, target.DisplayName), am.window)
+				})
+			}()
+		}, am.window)
+	})
+
+	return container.NewVBox(
+		widget.NewSeparator(),
+		widget.NewLabel("Copy an account's layout to another version"),
+		container.NewHBox(widget.NewLabel("Account:"), accountSelect, widget.NewLabel("To:"), targetSelect, addonSettingsCheck, copyButton),
+	)
+}
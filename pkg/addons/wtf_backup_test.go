@@ -0,0 +1,39 @@
+package addons
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffWTFIndexes(t *testing.T) {
+	from := map[string]uint32{"Config.wtf": 1, "Account/A/macros-cache.txt": 2, "Account/A/bindings-cache.wtf": 3}
+	to := map[string]uint32{"Config.wtf": 1, "Account/A/macros-cache.txt": 5, "Account/A/chat-cache.txt": 4}
+
+	want := WTFDiff{
+		Added:   []string{"Account/A/chat-cache.txt"},
+		Removed: []string{"Account/A/bindings-cache.wtf"},
+		Changed: []string{"Account/A/macros-cache.txt"},
+	}
+	if got := diffWTFIndexes(from, to); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffWTFIndexes() = %+v, want %+v", got, want)
+	}
+	if !diffWTFIndexes(from, from).Empty() {
+		t.Errorf("diffWTFIndexes() of identical indexes should be empty")
+	}
+}
+
+func TestIsAddonSettingsFile(t *testing.T) {
+	tests := map[string]bool{
+		"SavedVariables/pfUI.lua":            true,
+		"Realm/Hero/SavedVariables/pfUI.lua": true,
+		"Realm/Hero/AddOns.txt":              true,
+		"Realm/Hero/layout-local.txt":        false,
+		"bindings-cache.wtf":                 false,
+		"Realm/Hero/SavedVariables.lua":      false,
+	}
+	for path, want := range tests {
+		if got := isAddonSettingsFile(path); got != want {
+			t.Errorf("isAddonSettingsFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+// Package download fetches files over HTTP with cancellation, resume, retries and progress.
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+)
+
+const (
+	defaultAttempts  = 4
+	defaultStallTime = time.Minute
+	initialBackoff   = time.Second
+	maxBackoff       = 30 * time.Second
+	progressInterval = 100 * time.Millisecond
+)
+
+// ProgressFunc receives the number of bytes downloaded so far and the total size, or -1 if
+// the server didn't report it
+type ProgressFunc func(downloaded, total int64)
+
+// Options configures a download. The zero value is ready to use.
+type Options struct {
+	// Client sends the requests; nil uses HTTPClient
+	Client *http.Client
+	// Attempts is the number of tries before giving up, 0 means 4
+	Attempts int
+	// StallTimeout aborts an attempt that receives no data for this long, 0 means one minute
+	StallTimeout time.Duration
+	// Progress is called at most every 100ms while downloading and once when done
+	Progress ProgressFunc
+	// TempDir holds the partial file instead of dest's directory, so incomplete files never
+	// appear where they'd be picked up. It must be on the same volume as dest.
+	TempDir string
+	// Verify checks the complete file before it's moved to dest. If it fails, the partial
+	// file is removed so the next download starts over.
+	Verify func(path string) error
+}
+
+// statusError is returned for unexpected HTTP responses
+type statusError struct {
+	url  string
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server returned status %d for %s", e.code, e.url)
+}
+
+// permanentError marks failures that retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// File downloads url to dest. Data is written to dest.part, or a .part file in TempDir, which
+// is resumed with a Range request if an earlier attempt or run was interrupted, and renamed
+// to dest once complete. Network errors and server errors are retried with exponential backoff.
+func File(ctx context.Context, url, dest string, opts Options) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(dest), err)
+	}
+	attempts := opts.Attempts
+	if attempts <= 0 {
+		attempts = defaultAttempts
+	}
+
+	partPath := dest + ".part"
+	if opts.TempDir != "" {
+		if err := os.MkdirAll(opts.TempDir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %v", opts.TempDir, err)
+		}
+		partPath = filepath.Join(opts.TempDir, filepath.Base(dest)+".part")
+	}
+	backoff := initialBackoff
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fetch(ctx, url, partPath, opts); err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryable(err) || attempt == attempts {
+			return err
+		}
+
+		debug.Printf("Download of %s failed (attempt %d/%d), retrying in %v: %v", url, attempt, attempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.Verify != nil {
+		if err := opts.Verify(partPath); err != nil {
+			os.Remove(partPath)
+			os.Remove(validatorPath(partPath))
+			return err
+		}
+	}
+	if err := os.Rename(partPath, dest); err != nil {
+		return fmt.Errorf("failed to move download into place: %v", err)
+	}
+	os.Remove(validatorPath(partPath))
+	return nil
+}
+
+// fetch makes one attempt at downloading url into partPath, continuing a partial download
+// when the server still has the same file
+func fetch(ctx context.Context, url, partPath string, opts Options) error {
+	client := opts.Client
+	if client == nil {
+		client = HTTPClient
+	}
+	stallTimeout := opts.StallTimeout
+	if stallTimeout <= 0 {
+		stallTimeout = defaultStallTime
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &permanentError{fmt.Errorf("invalid download URL %s: %v", url, err)}
+	}
+
+	// Only resume when we can tell the server which version of the file we have
+	var offset int64
+	validator, _ := os.ReadFile(validatorPath(partPath))
+	if info, err := os.Stat(partPath); err == nil && info.Size() > 0 && len(validator) > 0 {
+		offset = info.Size()
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(validator))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && rangeStart(resp) == offset:
+		flags |= os.O_APPEND
+		debug.Printf("Resuming download of %s at %d bytes", url, offset)
+	case resp.StatusCode == http.StatusOK:
+		flags |= os.O_TRUNC
+		offset = 0
+	case resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial file doesn't match the server's, start over on the next attempt
+		os.Remove(partPath)
+		os.Remove(validatorPath(partPath))
+		return &statusError{url: url, code: resp.StatusCode}
+	default:
+		return &statusError{url: url, code: resp.StatusCode}
+	}
+
+	if offset == 0 {
+		saveValidator(partPath, resp)
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return &permanentError{fmt.Errorf("failed to create file %s: %v", partPath, err)}
+	}
+	defer out.Close()
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	// Cancel the request if no data arrives for a while
+	stallTimer := time.AfterFunc(stallTimeout, cancel)
+	defer stallTimer.Stop()
+
+	downloaded := offset
+	var lastProgress time.Time
+	buffer := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buffer)
+		if n > 0 {
+			stallTimer.Reset(stallTimeout)
+			if _, err := out.Write(buffer[:n]); err != nil {
+				return &permanentError{fmt.Errorf("failed to write file %s: %v", partPath, err)}
+			}
+			downloaded += int64(n)
+			if opts.Progress != nil && time.Since(lastProgress) >= progressInterval {
+				opts.Progress(downloaded, total)
+				lastProgress = time.Now()
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to download %s: %v", url, readErr)
+		}
+	}
+
+	if total >= 0 && downloaded != total {
+		return fmt.Errorf("failed to download %s: got %d of %d bytes", url, downloaded, total)
+	}
+	if err := out.Close(); err != nil {
+		return &permanentError{fmt.Errorf("failed to write file %s: %v", partPath, err)}
+	}
+	if opts.Progress != nil {
+		opts.Progress(downloaded, total)
+	}
+	return nil
+}
+
+// retryable reports whether a failed attempt may succeed when tried again
+func retryable(err error) bool {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code >= 500 || status.code == http.StatusTooManyRequests ||
+			status.code == http.StatusPartialContent || status.code == http.StatusRequestedRangeNotSatisfiable
+	}
+	return true
+}
+
+// rangeStart returns the first byte of a Content-Range response header, or -1
+func rangeStart(resp *http.Response) int64 {
+	value, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, _ := strings.Cut(value, "-")
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// validatorPath is where the ETag or Last-Modified of a partial download is kept
+func validatorPath(partPath string) string {
+	return partPath + ".validator"
+}
+
+// saveValidator records what identifies the file being downloaded, so a later attempt only
+// resumes if the server's file hasn't changed
+func saveValidator(partPath string, resp *http.Response) {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if validator == "" {
+		os.Remove(validatorPath(partPath))
+		return
+	}
+	os.WriteFile(validatorPath(partPath), []byte(validator), 0644)
+}
@@ -0,0 +1,110 @@
+package download
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileResumesPartialDownload(t *testing.T) {
+	content := strings.Repeat("turtle", 1000)
+	var rangeHeader atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader.Store(r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	os.WriteFile(dest+".part", []byte(content[:1000]), 0644)
+	os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0644)
+
+	var lastProgress, lastTotal int64
+	err := File(context.Background(), server.URL, dest, Options{Progress: func(downloaded, total int64) {
+		lastProgress, lastTotal = downloaded, total
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rangeHeader.Load(); got != "bytes=1000-" {
+		t.Errorf("expected a range request, got %q", got)
+	}
+	data, _ := os.ReadFile(dest)
+	if string(data) != content {
+		t.Errorf("downloaded %d bytes, want %d", len(data), len(content))
+	}
+	if lastProgress != int64(len(content)) || lastTotal != int64(len(content)) {
+		t.Errorf("final progress %d/%d, want %d", lastProgress, lastTotal, len(content))
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Error("partial file left behind")
+	}
+}
+
+func TestFileRestartsChangedDownload(t *testing.T) {
+	content := "new content"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	os.WriteFile(dest+".part", []byte("old"), 0644)
+	os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0644)
+
+	if err := File(context.Background(), server.URL, dest, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != content {
+		t.Errorf("got %q, want %q", data, content)
+	}
+}
+
+func TestFileRetriesServerErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := File(context.Background(), server.URL, dest, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", requests.Load())
+	}
+}
+
+func TestFileDoesNotRetryNotFound(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := File(context.Background(), server.URL, dest, Options{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", requests.Load())
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("destination created for a failed download")
+	}
+}
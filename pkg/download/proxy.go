@@ -0,0 +1,131 @@
+package download
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+)
+
+// HTTPClient is shared by everything that talks to update and download servers. It has
+// connection timeouts but no overall timeout, so large downloads aren't cut off.
+var HTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: SystemProxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	},
+}
+
+// macProxySettings are the proxies configured in the macOS network settings
+type macProxySettings struct {
+	httpProxy              *url.URL
+	httpsProxy             *url.URL
+	exceptions             []string
+	excludeSimpleHostnames bool
+}
+
+var (
+	macProxyOnce sync.Once
+	macProxy     *macProxySettings
+)
+
+// SystemProxy returns the proxy for a request. HTTP_PROXY, HTTPS_PROXY and NO_PROXY take
+// precedence; without them the proxy from the macOS network settings is used.
+func SystemProxy(req *http.Request) (*url.URL, error) {
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"} {
+		if os.Getenv(name) != "" {
+			return http.ProxyFromEnvironment(req)
+		}
+	}
+
+	macProxyOnce.Do(func() {
+		if runtime.GOOS != "darwin" {
+			return
+		}
+		output, err := exec.Command("scutil", "--proxy").Output()
+		if err != nil {
+			debug.Printf("Failed to read system proxy settings: %v", err)
+			return
+		}
+		macProxy = parseScutilProxy(string(output))
+	})
+	if macProxy == nil {
+		return nil, nil
+	}
+	return macProxy.proxyFor(req.URL), nil
+}
+
+// parseScutilProxy parses the output of "scutil --proxy"
+func parseScutilProxy(output string) *macProxySettings {
+	values := make(map[string]string)
+	settings := &macProxySettings{}
+	inExceptions := false
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), " : ")
+		if inExceptions {
+			if strings.TrimSpace(line) == "}" {
+				inExceptions = false
+			} else if found {
+				settings.exceptions = append(settings.exceptions, strings.ToLower(value))
+			}
+			continue
+		}
+		if !found {
+			continue
+		}
+		if key == "ExceptionsList" {
+			inExceptions = true
+			continue
+		}
+		values[key] = value
+	}
+
+	proxyURL := func(prefix string) *url.URL {
+		if values[prefix+"Enable"] != "1" || values[prefix+"Proxy"] == "" {
+			return nil
+		}
+		host := values[prefix+"Proxy"]
+		if port := values[prefix+"Port"]; port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		return &url.URL{Scheme: "http", Host: host}
+	}
+	settings.httpProxy = proxyURL("HTTP")
+	settings.httpsProxy = proxyURL("HTTPS")
+	settings.excludeSimpleHostnames = values["ExcludeSimpleHostnames"] == "1"
+	return settings
+}
+
+// proxyFor returns the proxy to use for a URL, or nil to connect directly
+func (s *macProxySettings) proxyFor(target *url.URL) *url.URL {
+	host := strings.ToLower(target.Hostname())
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	if s.excludeSimpleHostnames && !strings.Contains(host, ".") {
+		return nil
+	}
+	for _, exception := range s.exceptions {
+		domain := strings.TrimPrefix(exception, "*")
+		if host == exception || (strings.HasPrefix(domain, ".") && strings.HasSuffix(host, domain)) {
+			return nil
+		}
+	}
+
+	if target.Scheme == "https" {
+		return s.httpsProxy
+	}
+	return s.httpProxy
+}
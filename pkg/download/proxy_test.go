@@ -0,0 +1,51 @@
+package download
+
+import (
+	"net/url"
+	"testing"
+)
+
+const scutilOutput = `<dictionary> {
+  ExceptionsList : <array> {
+    0 : *.local
+    1 : internal.example.com
+  }
+  ExcludeSimpleHostnames : 1
+  FTPPassive : 1
+  HTTPEnable : 1
+  HTTPPort : 3128
+  HTTPProxy : proxy.example.com
+  HTTPSEnable : 1
+  HTTPSPort : 3129
+  HTTPSProxy : proxy.example.com
+}
+`
+
+func TestMacProxySettings(t *testing.T) {
+	settings := parseScutilProxy(scutilOutput)
+
+	tests := []struct {
+		target, want string
+	}{
+		{"https://api.github.com/repos", "http://proxy.example.com:3129"},
+		{"http://updater.project-epoch.net/api", "http://proxy.example.com:3128"},
+		{"http://printer.local/", ""},
+		{"https://internal.example.com/releases", ""},
+		{"http://intranet/", ""},
+		{"http://127.0.0.1:8080/", ""},
+	}
+	for _, tt := range tests {
+		target, _ := url.Parse(tt.target)
+		got := ""
+		if proxy := settings.proxyFor(target); proxy != nil {
+			got = proxy.String()
+		}
+		if got != tt.want {
+			t.Errorf("proxyFor(%s) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+
+	if disabled := parseScutilProxy("<dictionary> {\n  HTTPEnable : 0\n}\n"); disabled.proxyFor(&url.URL{Scheme: "http", Host: "example.com"}) != nil {
+		t.Error("disabled proxy was used")
+	}
+}
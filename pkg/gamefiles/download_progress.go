@@ -0,0 +1,169 @@
+package gamefiles
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// downloadWorkers is how many files are downloaded at once
+	downloadWorkers = 3
+	// downloadTempDir holds partial downloads inside the game directory
+	downloadTempDir = ".turtlesilicon-downloads"
+)
+
+type fileDownloadState int
+
+const (
+	fileWaiting fileDownloadState = iota
+	fileDownloading
+	fileDone
+	fileFailed
+)
+
+type fileProgress struct {
+	name       string
+	state      fileDownloadState
+	downloaded int64
+	total      int64 // -1 while unknown
+	baseline   int64 // bytes already there when the download resumed, -1 before the first update
+}
+
+// fileProgressText is how one file's progress is shown
+type fileProgressText struct {
+	label    string
+	progress float64
+}
+
+// downloadTracker collects byte progress from parallel downloads for the progress dialog
+type downloadTracker struct {
+	mutex   sync.Mutex
+	files   []fileProgress
+	started time.Time
+}
+
+func newDownloadTracker(files []File) *downloadTracker {
+	tracker := &downloadTracker{files: make([]fileProgress, len(files))}
+	for i, file := range files {
+		total := int64(-1)
+		if file.remote.Size > 0 {
+			total = file.remote.Size
+		}
+		tracker.files[i] = fileProgress{name: file.RelativePath, total: total, baseline: -1}
+	}
+	return tracker
+}
+
+func (t *downloadTracker) start(i int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.started.IsZero() {
+		t.started = time.Now()
+	}
+	t.files[i].state = fileDownloading
+}
+
+func (t *downloadTracker) update(i int, downloaded, total int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	file := &t.files[i]
+	if file.baseline < 0 {
+		file.baseline = downloaded
+	}
+	file.downloaded = downloaded
+	if total > 0 {
+		file.total = total
+	}
+}
+
+func (t *downloadTracker) finish(i int, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err != nil {
+		t.files[i].state = fileFailed
+		return
+	}
+	t.files[i].state = fileDone
+	if t.files[i].total > 0 {
+		t.files[i].downloaded = t.files[i].total
+	}
+}
+
+// snapshot returns the overall progress, a status line with speed and time left, and the
+// progress of each file
+func (t *downloadTracker) snapshot() (float64, string, []fileProgressText) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var downloaded, total, transferred int64
+	totalKnown := true
+	done := 0
+	texts := make([]fileProgressText, len(t.files))
+	for i, file := range t.files {
+		downloaded += file.downloaded
+		if file.total > 0 {
+			total += file.total
+		} else {
+			totalKnown = false
+		}
+		if file.baseline >= 0 {
+			transferred += file.downloaded - file.baseline
+		}
+
+		text := fileProgressText{label: file.name + ": waiting"}
+		switch file.state {
+		case fileDownloading:
+			text.label = fmt.Sprintf("%s: %s", file.name, formatBytes(file.downloaded))
+			if file.total > 0 {
+				text.label += " of " + formatBytes(file.total)
+				text.progress = float64(file.downloaded) / float64(file.total)
+			}
+		case fileDone:
+			done++
+			text = fileProgressText{label: file.name + ": done", progress: 1}
+		case fileFailed:
+			text.label = file.name + ": failed"
+		}
+		texts[i] = text
+	}
+
+	overall := float64(done) / float64(len(t.files))
+	status := fmt.Sprintf("Downloaded %d of %d files", done, len(t.files))
+	if totalKnown && total > 0 {
+		overall = float64(downloaded) / float64(total)
+		status = fmt.Sprintf("%s of %s", formatBytes(downloaded), formatBytes(total))
+	} else if downloaded > 0 {
+		status += fmt.Sprintf(", %s", formatBytes(downloaded))
+	}
+
+	if elapsed := time.Since(t.started); !t.started.IsZero() && elapsed > time.Second && transferred > 0 {
+		rate := float64(transferred) / elapsed.Seconds()
+		status += fmt.Sprintf(" at %s/s", formatBytes(int64(rate)))
+		if totalKnown && total > downloaded {
+			remaining := time.Duration(float64(total-downloaded)/rate) * time.Second
+			status += fmt.Sprintf(", about %s left", remaining.Round(time.Second))
+		}
+	}
+	return overall, status, texts
+}
+
+// formatBytes formats a byte count for display
+func formatBytes(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+}
@@ -0,0 +1,37 @@
+package gamefiles
+
+import (
+	"strings"
+	"testing"
+
+	"turtlesilicon/pkg/version"
+)
+
+func TestDownloadTrackerSnapshot(t *testing.T) {
+	files := []File{
+		{RequiredFile: version.RequiredFile{RelativePath: "Data/patch-A.MPQ"}, remote: remoteFile{Size: 1000}},
+		{RequiredFile: version.RequiredFile{RelativePath: "Data/patch-B.MPQ"}, remote: remoteFile{Size: 3000}},
+	}
+	tracker := newDownloadTracker(files)
+
+	tracker.start(0)
+	tracker.update(0, 500, 1000)
+	overall, status, texts := tracker.snapshot()
+	if overall != 500.0/4000 {
+		t.Errorf("overall progress %v, want %v", overall, 500.0/4000)
+	}
+	if !strings.Contains(status, "500 bytes of 3.9 KB") {
+		t.Errorf("unexpected status %q", status)
+	}
+	if texts[0].progress != 0.5 || texts[1].label != "Data/patch-B.MPQ: waiting" {
+		t.Errorf("unexpected file progress %+v", texts)
+	}
+
+	tracker.finish(0, nil)
+	tracker.start(1)
+	tracker.update(1, 3000, 3000)
+	tracker.finish(1, nil)
+	if overall, _, texts := tracker.snapshot(); overall != 1 || texts[1].label != "Data/patch-B.MPQ: done" {
+		t.Errorf("finished downloads: %v %+v", overall, texts)
+	}
+}
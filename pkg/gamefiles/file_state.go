@@ -0,0 +1,264 @@
+package gamefiles
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/download"
+)
+
+const metadataTimeout = 15 * time.Second
+
+// FileStatus is the state of a required file compared with its expected hash or the server's copy
+type FileStatus int
+
+const (
+	FileUpToDate FileStatus = iota
+	FileMissing
+	FileOutdated
+)
+
+// remoteFile is what the updater reports about a file in response to a HEAD request
+type remoteFile struct {
+	Size         int64
+	ETag         string
+	LastModified string
+}
+
+// validator identifies the server's version of the file
+func (r remoteFile) validator() string {
+	if r.ETag != "" {
+		return r.ETag
+	}
+	return r.LastModified
+}
+
+var md5ETagPattern = regexp.MustCompile(`^"?([0-9a-fA-F]{32})"?$`)
+
+// md5 returns the MD5 hash when the ETag is one, as is common for static file servers
+func (r remoteFile) md5() string {
+	if match := md5ETagPattern.FindStringSubmatch(r.ETag); match != nil {
+		return strings.ToLower(match[1])
+	}
+	return ""
+}
+
+// fileState records which version of a file was downloaded or checked, so a later check can
+// tell whether it changed without hashing the local file again
+type fileState struct {
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Validator string    `json:"validator"`
+}
+
+var fileStateMutex sync.Mutex
+
+func getFileStatePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "TurtleSilicon", "required_files.json"), nil
+}
+
+// loadFileStates returns the recorded file states of all game directories
+func loadFileStates() map[string]map[string]fileState {
+	states := make(map[string]map[string]fileState)
+	path, err := getFileStatePath()
+	if err != nil {
+		return states
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &states)
+	}
+	return states
+}
+
+// validator identifies the version of the file that is expected: its hash if one is
+// declared, otherwise the server's version
+func (f *File) validator() string {
+	if f.SHA256 != "" {
+		return "sha256:" + strings.ToLower(f.SHA256)
+	}
+	return f.remote.validator()
+}
+
+// recordFileState stores the state of a file after it was downloaded or found up to date
+func recordFileState(gamePath, relativePath, validator string) {
+	info, err := os.Stat(filepath.Join(gamePath, relativePath))
+	if err != nil {
+		return
+	}
+
+	fileStateMutex.Lock()
+	defer fileStateMutex.Unlock()
+
+	states := loadFileStates()
+	if states[gamePath] == nil {
+		states[gamePath] = make(map[string]fileState)
+	}
+	states[gamePath][relativePath] = fileState{Size: info.Size(), ModTime: info.ModTime(), Validator: validator}
+
+	path, err := getFileStatePath()
+	if err != nil {
+		return
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		debug.Printf("Failed to save required file state: %v", err)
+	}
+}
+
+// fetchRemoteFile asks the updater for a file's size and version
+func fetchRemoteFile(ctx context.Context, url string) (remoteFile, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return remoteFile{}, err
+	}
+	resp, err := download.HTTPClient.Do(req)
+	if err != nil {
+		return remoteFile{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return remoteFile{}, fmt.Errorf("server returned status %d for %s", resp.StatusCode, url)
+	}
+
+	return remoteFile{
+		Size:         resp.ContentLength,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// checkFile compares a local file with its declared hash, or else with the server's copy.
+// Files the server can't be reached for are assumed to be up to date.
+func checkFile(ctx context.Context, gamePath string, file *File, state *fileState) {
+	path := filepath.Join(gamePath, file.RelativePath)
+	info, statErr := os.Stat(path)
+
+	if file.SHA256 != "" {
+		if statErr != nil {
+			file.Status = FileMissing
+		} else {
+			file.Status = compareHash(path, info, file.SHA256, state)
+		}
+	} else {
+		remote, err := fetchRemoteFile(ctx, file.DownloadURL)
+		if statErr != nil {
+			// The server's version is still needed to record the file once it's downloaded
+			file.remote = remote
+			file.Status = FileMissing
+			return
+		}
+		if err != nil {
+			debug.Printf("Couldn't check %s for updates: %v", file.RelativePath, err)
+			file.Status = FileUpToDate
+			return
+		}
+		file.remote = remote
+		file.Status = compareFile(path, info, remote, state)
+	}
+
+	// Remember the checked version so the file isn't hashed again next time
+	if file.Status == FileUpToDate && (state == nil || state.Size != info.Size() ||
+		!state.ModTime.Equal(info.ModTime()) || state.Validator != file.validator()) {
+		recordFileState(gamePath, file.RelativePath, file.validator())
+	}
+}
+
+// compareHash decides whether a local file matches its declared SHA-256, trusting a
+// recorded check if the file hasn't changed since
+func compareHash(path string, info os.FileInfo, expected string, state *fileState) FileStatus {
+	validator := "sha256:" + strings.ToLower(expected)
+	if state != nil && state.Size == info.Size() && state.ModTime.Equal(info.ModTime()) && state.Validator == validator {
+		return FileUpToDate
+	}
+	if err := verifySHA256(path, expected); err != nil {
+		return FileOutdated
+	}
+	return FileUpToDate
+}
+
+// compareFile decides whether a local file differs from the updater's copy, by size, by a
+// recorded server version if the file hasn't changed since, or else by the MD5 in the ETag
+func compareFile(path string, info os.FileInfo, remote remoteFile, state *fileState) FileStatus {
+	if remote.Size >= 0 && remote.Size != info.Size() {
+		return FileOutdated
+	}
+
+	if state != nil && state.Size == info.Size() && state.ModTime.Equal(info.ModTime()) {
+		if state.Validator != "" && remote.validator() != "" && state.Validator != remote.validator() {
+			return FileOutdated
+		}
+		return FileUpToDate
+	}
+
+	if expected := remote.md5(); expected != "" {
+		actual, err := fileMD5(path)
+		if err != nil || actual != expected {
+			return FileOutdated
+		}
+	}
+	return FileUpToDate
+}
+
+// verifySHA256 checks that a file has the expected SHA-256 hash
+func verifySHA256(path, expected string) error {
+	actual, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", filepath.Base(path), strings.ToLower(expected), actual)
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileMD5(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,54 @@
+package gamefiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patch-A.MPQ")
+	os.WriteFile(path, []byte("patch"), 0644)
+	info, _ := os.Stat(path)
+	const patchMD5 = "e5c5e6dae38b284e8cf0bd1fb0efac03"
+
+	recorded := &fileState{Size: info.Size(), ModTime: info.ModTime(), Validator: `"v1"`}
+	tests := []struct {
+		name   string
+		remote remoteFile
+		state  *fileState
+		want   FileStatus
+	}{
+		{"different size", remoteFile{Size: 10}, nil, FileOutdated},
+		{"unknown version", remoteFile{Size: 5, ETag: `"v2"`}, nil, FileUpToDate},
+		{"recorded version", remoteFile{Size: 5, ETag: `"v1"`}, recorded, FileUpToDate},
+		{"new version", remoteFile{Size: 5, ETag: `"v2"`}, recorded, FileOutdated},
+		{"matching md5", remoteFile{Size: -1, ETag: `"` + patchMD5 + `"`}, nil, FileUpToDate},
+		{"different md5", remoteFile{Size: -1, ETag: `"00000000000000000000000000000000"`}, nil, FileOutdated},
+	}
+	for _, tt := range tests {
+		if got := compareFile(path, info, tt.remote, tt.state); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompareHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patch-A.MPQ")
+	os.WriteFile(path, []byte("patch"), 0644)
+	info, _ := os.Stat(path)
+	const patchSHA256 = "a4895eb44afc336fecbba6e520cd67e178dace0276655d102fceffa8e5f70570"
+
+	if got := compareHash(path, info, patchSHA256, nil); got != FileUpToDate {
+		t.Errorf("matching hash: got %v, want %v", got, FileUpToDate)
+	}
+	if got := compareHash(path, info, strings.Repeat("0", 64), nil); got != FileOutdated {
+		t.Errorf("different hash: got %v, want %v", got, FileOutdated)
+	}
+
+	recorded := &fileState{Size: info.Size(), ModTime: info.ModTime(), Validator: "sha256:" + strings.Repeat("0", 64)}
+	if got := compareHash(path, info, strings.Repeat("0", 64), recorded); got != FileUpToDate {
+		t.Errorf("recorded hash: got %v, want %v", got, FileUpToDate)
+	}
+}
@@ -0,0 +1,344 @@
+package gamefiles
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/download"
+	"turtlesilicon/pkg/realmlist"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/version"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// File is a required file of a game version and how it compares with the server's copy
+type File struct {
+	version.RequiredFile
+	Status FileStatus // Set by Check
+
+	remote remoteFile
+}
+
+// Check returns the required files that are missing from the game directory or differ from
+// their expected hash or the server's current version
+func Check(ctx context.Context, gamePath string, requiredFiles []version.RequiredFile) ([]File, error) {
+	if gamePath == "" {
+		return nil, fmt.Errorf("game path not set")
+	}
+
+	// Required files come on top of the base client, so check it's there first
+	if !utils.DirExists(filepath.Join(gamePath, "Data")) {
+		return nil, fmt.Errorf("no Data folder found in %s. Please select a valid WoW directory", gamePath)
+	}
+
+	files := make([]File, len(requiredFiles))
+	locale := realmlist.DetectLocale(gamePath)
+	fileStateMutex.Lock()
+	states := loadFileStates()[gamePath]
+	fileStateMutex.Unlock()
+
+	var wg sync.WaitGroup
+	for i := range requiredFiles {
+		files[i].RequiredFile = requiredFiles[i]
+		files[i].RelativePath = strings.ReplaceAll(files[i].RelativePath, version.LocalePlaceholder, locale)
+		var state *fileState
+		if recorded, ok := states[files[i].RelativePath]; ok {
+			state = &recorded
+		}
+		wg.Add(1)
+		go func(file *File, state *fileState) {
+			defer wg.Done()
+			checkFile(ctx, gamePath, file, state)
+		}(&files[i], state)
+	}
+	wg.Wait()
+
+	var changedFiles []File
+	missing, outdated := 0, 0
+	for _, file := range files {
+		switch file.Status {
+		case FileMissing:
+			missing++
+			debug.Printf("Missing required file: %s", file.RelativePath)
+		case FileOutdated:
+			outdated++
+			debug.Printf("Outdated required file: %s", file.RelativePath)
+		default:
+			continue
+		}
+		changedFiles = append(changedFiles, file)
+	}
+
+	debug.Printf("Required file check complete. Missing: %d files, outdated: %d files", missing, outdated)
+	return changedFiles, nil
+}
+
+// Blocking returns the files that have to be downloaded before launching, leaving out
+// optional files that were never installed
+func Blocking(files []File) []File {
+	var blocking []File
+	for _, file := range files {
+		if !(file.Optional && file.Status == FileMissing) {
+			blocking = append(blocking, file)
+		}
+	}
+	return blocking
+}
+
+// CanSkip reports whether the game still works without downloading files, which is the
+// case when they are only outdated or optional
+func CanSkip(files []File) bool {
+	for _, file := range files {
+		if file.Status == FileMissing && !file.Optional {
+			return false
+		}
+	}
+	return true
+}
+
+// ShowMissingFilesDialog asks the user whether to download missing or outdated files. If
+// onSkip is set, the user can also continue without downloading.
+func ShowMissingFilesDialog(myWindow fyne.Window, missingFiles []File, onDownload func(), onSkip func()) {
+	if len(missingFiles) == 0 {
+		return
+	}
+
+	// List missing and outdated files separately
+	missingFilesList := widget.NewRichText()
+	var missingText, outdatedText string
+	for _, file := range missingFiles {
+		name := file.RelativePath
+		if file.Optional {
+			name += " (optional)"
+		}
+		if file.Status == FileOutdated {
+			outdatedText += fmt.Sprintf("• %s\n", name)
+		} else {
+			missingText += fmt.Sprintf("• %s\n", name)
+		}
+	}
+	missingFilesText := ""
+	if missingText != "" {
+		missingFilesText += "**Missing game files:**\n\n" + missingText + "\n"
+	}
+	if outdatedText != "" {
+		missingFilesText += "**Game files with updates:**\n\n" + outdatedText + "\n"
+	}
+	missingFilesText += "Would you like to download these files now?"
+	missingFilesList.ParseMarkdown(missingFilesText)
+
+	// Create content container
+	content := container.NewVBox(
+		missingFilesList,
+		widget.NewSeparator(),
+	)
+
+	// Create custom dialog with Yes/No buttons
+	title := "Missing Game Files"
+	if missingText == "" {
+		title = "Game File Updates"
+	}
+	dismissLabel := "No, Cancel"
+	if onSkip != nil {
+		dismissLabel = "Skip"
+	}
+	confirmDialog := dialog.NewCustomConfirm(
+		title,
+		"Yes, Download",
+		dismissLabel,
+		content,
+		func(download bool) {
+			if download {
+				onDownload()
+			} else if onSkip != nil {
+				onSkip()
+			}
+		},
+		myWindow,
+	)
+
+	confirmDialog.Show()
+}
+
+// DownloadMissingFiles downloads the missing files in parallel with a progress dialog
+func DownloadMissingFiles(myWindow fyne.Window, gamePath string, missingFiles []File, onComplete func(bool)) {
+	if len(missingFiles) == 0 {
+		onComplete(true)
+		return
+	}
+
+	// Create progress dialog with overall and per-file progress
+	progressBar := widget.NewProgressBar()
+	progressBar.SetValue(0)
+
+	statusLabel := widget.NewLabel("Preparing download...")
+	cancelButton := widget.NewButton("Cancel", nil)
+
+	fileBars := make([]*widget.ProgressBar, len(missingFiles))
+	fileLabels := make([]*widget.Label, len(missingFiles))
+	fileRows := container.NewVBox()
+	for i, file := range missingFiles {
+		fileLabels[i] = widget.NewLabel(file.RelativePath + ": waiting")
+		fileBars[i] = widget.NewProgressBar()
+		fileRows.Add(container.NewVBox(fileLabels[i], fileBars[i]))
+	}
+	fileScroll := container.NewVScroll(fileRows)
+	fileScroll.SetMinSize(fyne.NewSize(500, 200))
+
+	content := container.NewVBox(
+		widget.NewRichTextFromMarkdown("## Downloading Game Files"),
+		widget.NewSeparator(),
+		statusLabel,
+		progressBar,
+		widget.NewSeparator(),
+		fileScroll,
+		widget.NewSeparator(),
+		container.NewCenter(cancelButton),
+	)
+
+	popup := widget.NewModalPopUp(container.NewPadded(content), myWindow.Canvas())
+	popup.Resize(fyne.NewSize(550, 450))
+	popup.Show()
+
+	// Track cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := false
+	cancelButton.OnTapped = func() {
+		cancelled = true
+		cancel()
+		popup.Hide()
+		onComplete(false)
+	}
+
+	tracker := newDownloadTracker(missingFiles)
+	updateProgress := func() {
+		overall, status, files := tracker.snapshot()
+		progressBar.SetValue(overall)
+		statusLabel.SetText(status)
+		for i, text := range files {
+			fileLabels[i].SetText(text.label)
+			fileBars[i].SetValue(text.progress)
+		}
+	}
+
+	go func() {
+		defer cancel()
+
+		ticker := time.NewTicker(250 * time.Millisecond)
+		done := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-ticker.C:
+					fyne.Do(updateProgress)
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		err := downloadFiles(ctx, gamePath, missingFiles, tracker)
+		ticker.Stop()
+		close(done)
+
+		fyne.Do(func() {
+			if cancelled {
+				return
+			}
+			popup.Hide()
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				onComplete(false)
+				return
+			}
+			onComplete(true)
+		})
+	}()
+}
+
+// downloadFiles downloads files with a few workers. The first failure cancels the others.
+func downloadFiles(ctx context.Context, gamePath string, files []File, tracker *downloadTracker) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+
+	for w := 0; w < min(downloadWorkers, len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				tracker.start(i)
+				err := downloadFile(ctx, gamePath, files[i], func(downloaded, total int64) {
+					tracker.update(i, downloaded, total)
+				})
+				tracker.finish(i, err)
+				if err != nil && ctx.Err() == nil {
+					debug.Printf("Failed to download %s: %v", files[i].RelativePath, err)
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("failed to download %s: %v", files[i].RelativePath, err)
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	for i := range files {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Only removed once empty, partial files of cancelled downloads are kept for resuming
+	os.Remove(filepath.Join(gamePath, downloadTempDir))
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// downloadFile downloads a single file to the correct location, replacing it only once the
+// download is complete and matches the expected hash
+func downloadFile(ctx context.Context, gamePath string, file File, progress download.ProgressFunc) error {
+	fullPath := filepath.Join(gamePath, file.RelativePath)
+
+	// Keep partial files out of Data/, where the client would try to load them
+	opts := download.Options{Progress: progress, TempDir: filepath.Join(gamePath, downloadTempDir)}
+	if file.SHA256 != "" {
+		opts.Verify = func(path string) error {
+			return verifySHA256(path, file.SHA256)
+		}
+	}
+	if err := download.File(ctx, file.DownloadURL, fullPath, opts); err != nil {
+		return err
+	}
+	recordFileState(gamePath, file.RelativePath, file.validator())
+
+	// The client also reads the realmlist from Config.wtf, which would keep the old server
+	if strings.EqualFold(filepath.Base(file.RelativePath), "realmlist.wtf") {
+		if err := realmlist.SyncConfig(gamePath, fullPath); err != nil {
+			debug.Printf("Failed to update realmlist in Config.wtf: %v", err)
+		}
+	}
+
+	debug.Printf("Successfully downloaded: %s", file.RelativePath)
+	return nil
+}
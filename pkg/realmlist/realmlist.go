@@ -0,0 +1,251 @@
+// Package realmlist reads and writes the realm server a WoW client connects to.
+package realmlist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+)
+
+// DefaultLocale is assumed when the client's locale can't be detected
+const DefaultLocale = "enUS"
+
+var knownLocales = []string{"enUS", "enGB", "deDE", "frFR", "esES", "esMX", "ruRU", "koKR", "zhCN", "zhTW", "ptBR", "ptPT", "itIT"}
+
+var (
+	realmlistLinePattern = wtfLinePattern("realmlist")
+	configRealmlist      = configPattern("realmlist")
+	configLocale         = regexp.MustCompile(`(?i)SET\s+locale\s+"([^"]*)"`)
+)
+
+// wtfLinePattern matches a "set <name> <value>" line of realmlist.wtf
+func wtfLinePattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?im)^[ \t]*set[ \t]+` + name + `[ \t]+"?([^"\r\n]*?)"?[ \t]*\r?$`)
+}
+
+// configPattern matches a SET <name> "<value>" setting of Config.wtf
+func configPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)SET\s+` + name + `\s+"[^"]*"`)
+}
+
+// DetectLocale returns the client's locale, taken from its Data/<locale> directory. If there
+// are several, the locale set in Config.wtf wins.
+func DetectLocale(gamePath string) string {
+	var found []string
+	for _, locale := range knownLocales {
+		if info, err := os.Stat(filepath.Join(gamePath, "Data", locale)); err == nil && info.IsDir() {
+			found = append(found, locale)
+		}
+	}
+	if len(found) == 0 {
+		debug.Printf("No locale directory found in %s, assuming %s", gamePath, DefaultLocale)
+		return DefaultLocale
+	}
+
+	if content, err := os.ReadFile(configPath(gamePath)); err == nil {
+		if match := configLocale.FindStringSubmatch(string(content)); match != nil {
+			for _, locale := range found {
+				if strings.EqualFold(locale, match[1]) {
+					return locale
+				}
+			}
+		}
+	}
+	return found[0]
+}
+
+// Path returns the realmlist.wtf the client of a WoW version reads. Vanilla clients read it
+// from the game folder, later ones from Data/<locale>.
+func Path(gamePath, wowVersion string) string {
+	if strings.HasPrefix(wowVersion, "1.") {
+		return filepath.Join(gamePath, "realmlist.wtf")
+	}
+	return filepath.Join(gamePath, "Data", DetectLocale(gamePath), "realmlist.wtf")
+}
+
+func configPath(gamePath string) string {
+	return filepath.Join(gamePath, "WTF", "Config.wtf")
+}
+
+// Get returns the realm server the client connects to. Config.wtf is read first, as the
+// client prefers it over realmlist.wtf.
+func Get(gamePath, wowVersion string) (string, error) {
+	if content, err := os.ReadFile(configPath(gamePath)); err == nil {
+		if server := parseConfigRealmlist(string(content)); server != "" {
+			return server, nil
+		}
+	}
+
+	realmlistPath := Path(gamePath, wowVersion)
+	content, err := os.ReadFile(realmlistPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read realmlist.wtf: %v", err)
+	}
+	if match := realmlistLinePattern.FindStringSubmatch(string(content)); match != nil {
+		return strings.TrimSpace(match[1]), nil
+	}
+	return "", fmt.Errorf("no realmlist set in %s", realmlistPath)
+}
+
+// Set points the client at a realm server, and at a patch server unless patchlist is empty,
+// in which case a previously set patch server is removed. Both realmlist.wtf and Config.wtf
+// are updated, so neither keeps pointing at the old server.
+func Set(gamePath, wowVersion, server, patchlist string) error {
+	server = strings.TrimSpace(server)
+	patchlist = strings.TrimSpace(patchlist)
+	if server == "" {
+		return fmt.Errorf("realm server not set")
+	}
+
+	// The client creates its locale folder on install, so a missing one means a wrong game path
+	realmlistPath := Path(gamePath, wowVersion)
+	if info, err := os.Stat(filepath.Dir(realmlistPath)); err != nil || !info.IsDir() {
+		return fmt.Errorf("folder %s not found. Please check the game path", filepath.Dir(realmlistPath))
+	}
+	content, _ := os.ReadFile(realmlistPath)
+	realmlistText := setWTFLine(string(content), "realmlist", server)
+	if patchlist != "" {
+		realmlistText = setWTFLine(realmlistText, "patchlist", patchlist)
+	} else {
+		realmlistText = removeWTFLine(realmlistText, "patchlist")
+	}
+	if err := os.WriteFile(realmlistPath, []byte(realmlistText), 0644); err != nil {
+		return fmt.Errorf("failed to write realmlist.wtf: %v", err)
+	}
+
+	settings := map[string]string{"realmlist": server}
+	if patchlist != "" {
+		settings["patchlist"] = patchlist
+	}
+	if err := setConfigValues(gamePath, settings); err != nil {
+		return err
+	}
+	if patchlist == "" {
+		if err := removeConfigValue(gamePath, "patchlist"); err != nil {
+			return err
+		}
+	}
+	debug.Printf("Set realmlist to %s in %s", server, realmlistPath)
+	return nil
+}
+
+// SyncConfig copies the server in a realmlist.wtf to Config.wtf, after the file was replaced
+// by a download
+func SyncConfig(gamePath, realmlistPath string) error {
+	content, err := os.ReadFile(realmlistPath)
+	if err != nil {
+		return fmt.Errorf("failed to read realmlist.wtf: %v", err)
+	}
+	match := realmlistLinePattern.FindStringSubmatch(string(content))
+	if match == nil {
+		return nil
+	}
+	return setConfigValues(gamePath, map[string]string{"realmlist": strings.TrimSpace(match[1])})
+}
+
+// setConfigValues updates settings in Config.wtf. A missing Config.wtf is left for the
+// client to create.
+func setConfigValues(gamePath string, settings map[string]string) error {
+	path := configPath(gamePath)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read Config.wtf: %v", err)
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	configText := string(content)
+	for _, name := range names {
+		re := configPattern(name)
+		newSetting := fmt.Sprintf(`SET %s "%s"`, name, settings[name])
+		if re.MatchString(configText) {
+			configText = re.ReplaceAllLiteralString(configText, newSetting)
+		} else {
+			if configText != "" && !strings.HasSuffix(configText, "\n") {
+				configText += "\n"
+			}
+			configText += newSetting + "\n"
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(configText), 0644); err != nil {
+		return fmt.Errorf("failed to write Config.wtf: %v", err)
+	}
+	return nil
+}
+
+// removeConfigValue removes a setting from Config.wtf, if it's there
+func removeConfigValue(gamePath, name string) error {
+	path := configPath(gamePath)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read Config.wtf: %v", err)
+	}
+
+	re := regexp.MustCompile(`(?im)^[ \t]*SET[ \t]+` + name + `[ \t]+"[^"]*"[ \t]*\r?(\n|$)`)
+	if !re.Match(content) {
+		return nil
+	}
+	if err := os.WriteFile(path, re.ReplaceAll(content, nil), 0644); err != nil {
+		return fmt.Errorf("failed to write Config.wtf: %v", err)
+	}
+	return nil
+}
+
+func parseConfigRealmlist(configText string) string {
+	match := configRealmlist.FindString(configText)
+	if match == "" {
+		return ""
+	}
+	start := strings.Index(match, `"`)
+	return strings.TrimSpace(strings.Trim(match[start:], `"`))
+}
+
+// setWTFLine replaces a "set" line of a realmlist.wtf, keeping the other settings. A missing
+// realmlist line is added at the top, other lines at the end.
+func setWTFLine(content, name, value string) string {
+	re := wtfLinePattern(name)
+	line := "set " + name + " " + value
+	if re.MatchString(content) {
+		replaced := false
+		return re.ReplaceAllStringFunc(content, func(match string) string {
+			// Later lines would override the first one, so drop them
+			if replaced {
+				return ""
+			}
+			replaced = true
+			if strings.HasSuffix(match, "\r") {
+				return line + "\r"
+			}
+			return line
+		})
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if name == "realmlist" {
+		return line + "\n" + content
+	}
+	return content + line + "\n"
+}
+
+// removeWTFLine drops every "set" line of a realmlist.wtf with the given name
+func removeWTFLine(content, name string) string {
+	re := regexp.MustCompile(`(?im)^[ \t]*set[ \t]+` + name + `[ \t]+[^\r\n]*\r?(\n|$)`)
+	return re.ReplaceAllString(content, "")
+}
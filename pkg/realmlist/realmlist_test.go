@@ -0,0 +1,141 @@
+package realmlist
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectLocale(t *testing.T) {
+	gamePath := t.TempDir()
+	if got := DetectLocale(gamePath); got != DefaultLocale {
+		t.Errorf("no locale directory: got %s, want %s", got, DefaultLocale)
+	}
+
+	os.MkdirAll(filepath.Join(gamePath, "Data", "deDE"), 0755)
+	if got := DetectLocale(gamePath); got != "deDE" {
+		t.Errorf("got %s, want deDE", got)
+	}
+
+	os.MkdirAll(filepath.Join(gamePath, "Data", "frFR"), 0755)
+	os.MkdirAll(filepath.Join(gamePath, "WTF"), 0755)
+	os.WriteFile(filepath.Join(gamePath, "WTF", "Config.wtf"), []byte("SET locale \"frFR\"\n"), 0644)
+	if got := DetectLocale(gamePath); got != "frFR" {
+		t.Errorf("with Config.wtf locale: got %s, want frFR", got)
+	}
+}
+
+func TestSetWTFLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty", "", "set realmlist new.example.com\n"},
+		{"replace", "set realmlist old.example.com\nset patchlist patch.example.com\n", "set realmlist new.example.com\nset patchlist patch.example.com\n"},
+		{"quoted", "SET realmlist \"old.example.com\"\n", "set realmlist new.example.com\n"},
+		{"crlf", "set realmlist old.example.com\r\n", "set realmlist new.example.com\r\n"},
+		{"no realmlist", "set patchlist patch.example.com", "set realmlist new.example.com\nset patchlist patch.example.com\n"},
+	}
+	for _, tt := range tests {
+		if got := setWTFLine(tt.content, "realmlist", "new.example.com"); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := setWTFLine("set realmlist a.example.com\n", "patchlist", "p.example.com"); got != "set realmlist a.example.com\nset patchlist p.example.com\n" {
+		t.Errorf("added patchlist: got %q", got)
+	}
+}
+
+func TestSetUpdatesBothLocations(t *testing.T) {
+	gamePath := t.TempDir()
+	os.MkdirAll(filepath.Join(gamePath, "Data", "esES"), 0755)
+	os.MkdirAll(filepath.Join(gamePath, "WTF"), 0755)
+	configPath := filepath.Join(gamePath, "WTF", "Config.wtf")
+	os.WriteFile(configPath, []byte("SET realmlist \"old.example.com\"\nSET gxApi \"d3d9\"\n"), 0644)
+
+	if err := Set(gamePath, "3.3.5a", "new.example.com", "patch.example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	realmlist, err := os.ReadFile(filepath.Join(gamePath, "Data", "esES", "realmlist.wtf"))
+	if err != nil || string(realmlist) != "set realmlist new.example.com\nset patchlist patch.example.com\n" {
+		t.Errorf("realmlist.wtf = %q, %v", realmlist, err)
+	}
+	config, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(config), `SET realmlist "new.example.com"`) || !strings.Contains(string(config), `SET patchlist "patch.example.com"`) ||
+		!strings.Contains(string(config), `SET gxApi "d3d9"`) {
+		t.Errorf("Config.wtf = %q", config)
+	}
+	if server, err := Get(gamePath, "3.3.5a"); err != nil || server != "new.example.com" {
+		t.Errorf("Get() = %q, %v", server, err)
+	}
+}
+
+func TestPathLayouts(t *testing.T) {
+	gamePath := t.TempDir()
+	os.MkdirAll(filepath.Join(gamePath, "Data", "deDE"), 0755)
+
+	tests := []struct {
+		wowVersion string
+		want       string
+	}{
+		{"1.12.1", filepath.Join(gamePath, "realmlist.wtf")},
+		{"2.4.3", filepath.Join(gamePath, "Data", "deDE", "realmlist.wtf")},
+		{"3.3.5a", filepath.Join(gamePath, "Data", "deDE", "realmlist.wtf")},
+	}
+	for _, tt := range tests {
+		if got := Path(gamePath, tt.wowVersion); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.wowVersion, got, tt.want)
+		}
+	}
+}
+
+func TestSetVanillaLayout(t *testing.T) {
+	gamePath := t.TempDir()
+	if err := Set(gamePath, "1.12.1", "logon.example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(filepath.Join(gamePath, "realmlist.wtf")); err != nil || string(content) != "set realmlist logon.example.com\n" {
+		t.Errorf("realmlist.wtf = %q, %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(gamePath, "Data")); !os.IsNotExist(err) {
+		t.Errorf("Set created a Data folder for a vanilla client")
+	}
+}
+
+func TestSetWithoutLocaleFolder(t *testing.T) {
+	gamePath := t.TempDir()
+	if err := Set(gamePath, "3.3.5a", "logon.example.com", ""); err == nil {
+		t.Errorf("expected an error without a locale folder")
+	}
+	if _, err := os.Stat(filepath.Join(gamePath, "Data", DefaultLocale)); !os.IsNotExist(err) {
+		t.Errorf("Set created Data/%s", DefaultLocale)
+	}
+}
+
+func TestSetRemovesPatchlist(t *testing.T) {
+	gamePath := t.TempDir()
+	os.MkdirAll(filepath.Join(gamePath, "Data", "enUS"), 0755)
+	os.MkdirAll(filepath.Join(gamePath, "WTF"), 0755)
+	configPath := filepath.Join(gamePath, "WTF", "Config.wtf")
+	os.WriteFile(configPath, []byte("SET gxApi \"d3d9\"\n"), 0644)
+
+	if err := Set(gamePath, "3.3.5a", "a.example.com", "patch.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(gamePath, "3.3.5a", "b.example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	realmlist, _ := os.ReadFile(filepath.Join(gamePath, "Data", "enUS", "realmlist.wtf"))
+	if string(realmlist) != "set realmlist b.example.com\n" {
+		t.Errorf("realmlist.wtf = %q", realmlist)
+	}
+	config, _ := os.ReadFile(configPath)
+	if string(config) != "SET gxApi \"d3d9\"\nSET realmlist \"b.example.com\"\n" {
+		t.Errorf("Config.wtf = %q", config)
+	}
+}
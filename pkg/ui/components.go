@@ -221,12 +221,17 @@ func createBottomBar(myWindow fyne.Window) fyne.CanvasObject {
 		container.NewCenter(playButtonText),
 	)
 
-	// Addons button
-	addonsButton := widget.NewButton("Addons", func() {
-		addonManager := addons.NewAddonManager(myWindow)
+	// Addons button, badged with the number of updatable addons found by the background checker
+	addonsButton = widget.NewButton("Addons", func() {
+		addonManager := addons.NewAddonManager(myWindow, GetCurrentVersion())
 		addonManager.ShowAddonManager()
 	})
 
+	updateAddonsButtonBadge()
+	addons.SetUpdateCountListener(func() {
+		fyne.Do(updateAddonsButtonBadge)
+	})
+
 	leftButtons := container.NewHBox(
 		optionsButton,
 		troubleshootingButton,
@@ -737,3 +742,15 @@ func showGraphicsSettingHelpPopup(title, description, impact string) {
 
 	helpPopup.Show()
 }
+
+// updateAddonsButtonBadge shows the cached number of addon updates for the current version
+func updateAddonsButtonBadge() {
+	if addonsButton == nil {
+		return
+	}
+	if count := addons.CachedUpdateCount(GetCurrentVersion()); count > 0 {
+		addonsButton.SetText(fmt.Sprintf("Addons (%d)", count))
+	} else {
+		addonsButton.SetText("Addons")
+	}
+}
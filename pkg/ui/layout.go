@@ -143,6 +143,7 @@ func createPathSelectionForm(myWindow fyne.Window) *widget.Form {
 		widget.NewFormItem("Game Path:", container.NewBorder(nil, nil, nil, widget.NewButton("Set/Change", func() {
 			SelectCurrentVersionGamePath(myWindow)
 		}), turtlewowPathLabel)),
+		widget.NewFormItem("Realm:", createRealmSelector(myWindow)),
 	)
 
 	return pathSelectionForm
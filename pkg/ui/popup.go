@@ -47,6 +47,10 @@ func showOptionsPopup() {
 		showTerminalCheckbox,
 		vanillaTweaksCheckbox,
 		autoDeleteWdbCheckbox,
+		container.NewHBox(widget.NewLabel("App updates:"), createUpdateChannelSelect(), widget.NewButton("Roll Back...", func() {
+			showRollbackDialog(currentWindow)
+		})),
+		container.NewBorder(nil, nil, widget.NewLabel("Update source:"), nil, createUpdateSourceEntry()),
 		widget.NewSeparator(),
 		container.NewBorder(nil, nil, nil, container.NewHBox(enableOptionAsAltButton, disableOptionAsAltButton), optionAsAltStatusLabel),
 	)
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/realmlist"
+	"turtlesilicon/pkg/version"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// noRealmOption leaves the realmlist as it is when launching
+const noRealmOption = "Don't change realmlist"
+
+// createRealmSelector creates the realm select and its Manage button for the main window
+func createRealmSelector(myWindow fyne.Window) fyne.CanvasObject {
+	realmSelect = widget.NewSelect(nil, func(selected string) {
+		currentVer := GetCurrentVersion()
+		if currentVer == nil {
+			return
+		}
+		if selected == noRealmOption {
+			selected = ""
+		}
+		if currentVer.Settings.LastRealm == selected {
+			return
+		}
+		currentVer.Settings.LastRealm = selected
+		if err := SaveCurrentVersion(currentVer); err != nil {
+			debug.Printf("Failed to save selected realm: %v", err)
+		}
+		debug.Printf("Selected realm for %s: %q", currentVer.ID, selected)
+	})
+	refreshRealmSelector()
+
+	manageButton := widget.NewButton("Manage", func() {
+		showManageRealmsDialog(myWindow)
+	})
+	return container.NewBorder(nil, nil, nil, manageButton, realmSelect)
+}
+
+// refreshRealmSelector shows the current version's saved realms in the realm select
+func refreshRealmSelector() {
+	if realmSelect == nil {
+		return
+	}
+	currentVer := GetCurrentVersion()
+	options := []string{noRealmOption}
+	selected := noRealmOption
+	if currentVer != nil {
+		for _, realm := range currentVer.Realms {
+			options = append(options, realm.Name)
+		}
+		if realm := currentVer.SelectedRealm(); realm != nil {
+			selected = realm.Name
+		}
+	}
+	realmSelect.Options = options
+	realmSelect.SetSelected(selected)
+	realmSelect.Refresh()
+}
+
+// showManageRealmsDialog lets the user add, edit and remove the current version's realms
+func showManageRealmsDialog(myWindow fyne.Window) {
+	currentVer := GetCurrentVersion()
+	if currentVer == nil {
+		dialog.ShowError(fmt.Errorf("no current version selected"), myWindow)
+		return
+	}
+
+	rows := container.NewVBox()
+	var refreshRows func()
+	saveRealms := func(realms []version.Realm) {
+		currentVer.Realms = realms
+		if currentVer.SelectedRealm() == nil {
+			currentVer.Settings.LastRealm = ""
+		}
+		if err := SaveCurrentVersion(currentVer); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save realms: %v", err), myWindow)
+		}
+		refreshRows()
+		refreshRealmSelector()
+	}
+
+	refreshRows = func() {
+		rows.RemoveAll()
+		if len(currentVer.Realms) == 0 {
+			rows.Add(widget.NewLabel("No saved realms. Add the servers you play on to switch between them before launching."))
+		}
+		for i, realm := range currentVer.Realms {
+			index := i
+			label := widget.NewLabel(fmt.Sprintf("%s (%s)", realm.Name, realm.Realmlist))
+			editButton := widget.NewButton("Edit", func() {
+				showRealmForm(myWindow, currentVer, index, func(edited version.Realm) {
+					realms := append([]version.Realm(nil), currentVer.Realms...)
+					// Keep the selection when the selected realm is renamed
+					if currentVer.Settings.LastRealm == realms[index].Name {
+						currentVer.Settings.LastRealm = edited.Name
+					}
+					realms[index] = edited
+					saveRealms(realms)
+				})
+			})
+			removeButton := widget.NewButton("Remove", func() {
+				dialog.ShowConfirm("Remove Realm", fmt.Sprintf("Remove %s from the saved realms?", currentVer.Realms[index].Name), func(confirmed bool) {
+					if !confirmed {
+						return
+					}
+					realms := append([]version.Realm(nil), currentVer.Realms[:index]...)
+					saveRealms(append(realms, currentVer.Realms[index+1:]...))
+				}, myWindow)
+			})
+			rows.Add(container.NewBorder(nil, nil, nil, container.NewHBox(editButton, removeButton), label))
+		}
+	}
+	refreshRows()
+
+	addButton := widget.NewButton("Add Realm", func() {
+		showRealmForm(myWindow, currentVer, -1, func(added version.Realm) {
+			saveRealms(append(append([]version.Realm(nil), currentVer.Realms...), added))
+		})
+	})
+
+	current := "unknown"
+	if currentVer.GamePath != "" {
+		if server, err := realmlist.Get(currentVer.GamePath, currentVer.WoWVersion); err == nil {
+			current = server
+		}
+	}
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Realmlist currently set in the game: %s", current)),
+		widget.NewSeparator(),
+		rows,
+		widget.NewSeparator(),
+		container.NewHBox(addButton),
+	)
+
+	realmsDialog := dialog.NewCustom(fmt.Sprintf("Realms for %s", currentVer.DisplayName), "Close", content, myWindow)
+	realmsDialog.Resize(fyne.NewSize(550, 0))
+	realmsDialog.Show()
+}
+
+// showRealmForm asks for a realm's details, editing the realm at index or adding one if
+// index is -1
+func showRealmForm(myWindow fyne.Window, gameVersion *version.GameVersion, index int, onSave func(version.Realm)) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("My Server")
+	realmlistEntry := widget.NewEntry()
+	realmlistEntry.SetPlaceHolder("logon.example.com")
+	patchlistEntry := widget.NewEntry()
+	patchlistEntry.SetPlaceHolder("Optional")
+
+	title := "Add Realm"
+	if index >= 0 {
+		title = "Edit Realm"
+		realm := gameVersion.Realms[index]
+		nameEntry.SetText(realm.Name)
+		realmlistEntry.SetText(realm.Realmlist)
+		patchlistEntry.SetText(realm.Patchlist)
+	}
+
+	nameEntry.Validator = func(text string) error {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return fmt.Errorf("enter a name")
+		}
+		if text == noRealmOption {
+			return fmt.Errorf("choose another name")
+		}
+		for i, realm := range gameVersion.Realms {
+			if i != index && strings.EqualFold(realm.Name, text) {
+				return fmt.Errorf("a realm with this name already exists")
+			}
+		}
+		return nil
+	}
+	realmlistEntry.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("enter the realmlist address")
+		}
+		if strings.ContainsAny(text, "\"\n") {
+			return fmt.Errorf("invalid address")
+		}
+		return nil
+	}
+	patchlistEntry.Validator = func(text string) error {
+		if strings.ContainsAny(text, "\"\n") {
+			return fmt.Errorf("invalid address")
+		}
+		return nil
+	}
+
+	form := dialog.NewForm(title, "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Realmlist", realmlistEntry),
+		widget.NewFormItem("Patchlist", patchlistEntry),
+	}, func(save bool) {
+		if !save {
+			return
+		}
+		onSave(version.Realm{
+			Name:      strings.TrimSpace(nameEntry.Text),
+			Realmlist: strings.TrimSpace(realmlistEntry.Text),
+			Patchlist: strings.TrimSpace(patchlistEntry.Text),
+		})
+	}, myWindow)
+	form.Resize(fyne.NewSize(450, 0))
+	form.Show()
+}
+
+// applySelectedRealm points the game at the version's selected realm, if there is one
+func applySelectedRealm(gameVersion *version.GameVersion) error {
+	realm := gameVersion.SelectedRealm()
+	if realm == nil || gameVersion.GamePath == "" {
+		return nil
+	}
+	debug.Printf("Switching %s to realm %s (%s)", gameVersion.ID, realm.Name, realm.Realmlist)
+	return realmlist.Set(gameVersion.GamePath, gameVersion.WoWVersion, realm.Realmlist, realm.Patchlist)
+}
+
+// requiredFilesToCheck returns the required files to check before patching or launching. A
+// server's own realmlist.wtf is left out while a saved realm is selected, since it's
+// rewritten anyway.
+func requiredFilesToCheck(gameVersion *version.GameVersion) []version.RequiredFile {
+	if gameVersion.SelectedRealm() == nil {
+		return gameVersion.RequiredFiles
+	}
+	var files []version.RequiredFile
+	for _, file := range gameVersion.RequiredFiles {
+		if !strings.EqualFold(filepath.Base(file.RelativePath), "realmlist.wtf") {
+			files = append(files, file)
+		}
+	}
+	return files
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"turtlesilicon/pkg/addons"
 	"turtlesilicon/pkg/debug"
 	"turtlesilicon/pkg/patching"
 	"turtlesilicon/pkg/paths"
@@ -67,6 +68,9 @@ func CreateUI(myWindow fyne.Window) fyne.CanvasObject {
 	// Initial UI state update
 	UpdateAllStatuses()
 
+	// Check for addon updates in the background if enabled in the addon manager settings
+	addons.StartBackgroundChecker(myWindow, GetCurrentVersion)
+
 	// Create layout with header at top, main content moved up to avoid bottom bar, and bottom bar
 	// Use VBox to position main content higher up instead of centering it
 	mainContentContainer := container.NewVBox(
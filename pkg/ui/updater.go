@@ -1,9 +1,10 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strings"
-	"time"
 
 	"turtlesilicon/pkg/debug"
 	"turtlesilicon/pkg/utils"
@@ -32,8 +33,19 @@ func ShowUpdateDialog(updateInfo *utils.UpdateInfo, currentVersion string, myWin
 		return
 	}
 
+	// Updates are only installed when their checksum can be verified
+	checksumAsset := utils.FindChecksumAsset(updateInfo, dmgAsset.Name)
+	if checksumAsset == nil {
+		dialog.ShowError(fmt.Errorf("the latest release doesn't publish a checksum for %s, so it can't be verified. Please download it manually from GitHub", dmgAsset.Name), myWindow)
+		return
+	}
+
 	// Create content for the update dialog
-	titleLabel := widget.NewLabel(fmt.Sprintf("Update Available: v%s", latestVersion))
+	title := fmt.Sprintf("Update Available: v%s", latestVersion)
+	if updateInfo.IsPrerelease() {
+		title = fmt.Sprintf("Beta Available: v%s", latestVersion)
+	}
+	titleLabel := widget.NewLabel(title)
 	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
 
 	currentVersionLabel := widget.NewLabel(fmt.Sprintf("Current version: v%s", currentVersion))
@@ -83,28 +95,33 @@ func ShowUpdateDialog(updateInfo *utils.UpdateInfo, currentVersion string, myWin
 		progressLabel.Show()
 		progressLabel.SetText("Starting download...")
 
-		// Disable dialog closing during download
-		d.SetButtons([]fyne.CanvasObject{})
+		// Only allow cancelling while downloading
+		ctx, cancel := context.WithCancel(context.Background())
+		d.SetButtons([]fyne.CanvasObject{
+			widget.NewButton("Cancel", cancel),
+		})
 
 		go func() {
+			defer cancel()
+
 			// Download with progress
-			downloadPath, err := utils.DownloadUpdate(dmgAsset.BrowserDownloadURL, func(downloaded, total int64) {
-				// Update progress on UI thread
-				fyne.NewAnimation(
-					time.Millisecond*50,
-					func(float32) {
-						if total > 0 {
-							progress := float64(downloaded) / float64(total)
-							progressBar.SetValue(progress)
-							progressLabel.SetText(fmt.Sprintf("Downloaded: %s / %s (%.1f%%)",
-								formatFileSize(downloaded), formatFileSize(total), progress*100))
-						}
-					},
-				).Curve = fyne.AnimationLinear
+			downloadPath, err := utils.DownloadUpdate(ctx, dmgAsset.BrowserDownloadURL, func(downloaded, total int64) {
+				fyne.Do(func() {
+					if total > 0 {
+						progress := float64(downloaded) / float64(total)
+						progressBar.SetValue(progress)
+						progressLabel.SetText(fmt.Sprintf("Downloaded: %s / %s (%.1f%%)",
+							formatFileSize(downloaded), formatFileSize(total), progress*100))
+					}
+				})
 			})
 
 			if err != nil {
-				progressLabel.SetText(fmt.Sprintf("Download failed: %v", err))
+				if ctx.Err() != nil {
+					progressLabel.SetText("Download cancelled. It will resume where it left off next time.")
+				} else {
+					progressLabel.SetText(fmt.Sprintf("Download failed: %v", err))
+				}
 				debug.Printf("Download failed: %v", err)
 
 				// Re-enable close button
@@ -114,11 +131,29 @@ func ShowUpdateDialog(updateInfo *utils.UpdateInfo, currentVersion string, myWin
 				return
 			}
 
-			progressLabel.SetText("Installing update...")
+			// Installing can't be cancelled
+			fyne.Do(func() { d.SetButtons([]fyne.CanvasObject{}) })
+
+			progressLabel.SetText("Verifying download...")
 			progressBar.SetValue(1.0)
 
+			if err := utils.VerifyUpdateChecksum(downloadPath, *checksumAsset, dmgAsset.Name); err != nil {
+				os.Remove(downloadPath)
+				progressLabel.SetText(fmt.Sprintf("Verification failed: %v", err))
+				debug.Printf("Update verification failed: %v", err)
+
+				// Re-enable close button
+				d.SetButtons([]fyne.CanvasObject{
+					widget.NewButton("Close", func() { d.Hide() }),
+				})
+				return
+			}
+
+			progressLabel.SetText("Installing update...")
+
 			// Install update
-			err = utils.InstallUpdate(downloadPath)
+			err = utils.InstallUpdate(downloadPath, latestVersion)
+			os.Remove(downloadPath)
 			if err != nil {
 				progressLabel.SetText(fmt.Sprintf("Installation failed: %v", err))
 				debug.Printf("Installation failed: %v", err)
@@ -166,6 +201,153 @@ func ShowUpdateDialog(updateInfo *utils.UpdateInfo, currentVersion string, myWin
 	d.Show()
 }
 
+// showRollbackDialog lists the previous app versions kept by the updater and lets the user
+// roll back to one of them
+func showRollbackDialog(myWindow fyne.Window) {
+	backups, err := utils.ListAppBackups()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to list previous versions: %v", err), myWindow)
+		return
+	}
+	if len(backups) == 0 {
+		dialog.ShowInformation("Roll Back", "No previous versions are available. The updater keeps the versions it replaces.", myWindow)
+		return
+	}
+
+	var rollbackDialog dialog.Dialog
+	rows := container.NewVBox()
+	for _, backup := range backups {
+		backup := backup
+		label := widget.NewLabel(fmt.Sprintf("v%s (replaced %s)", backup.Version, backup.Created.Format("Jan 2, 2006 15:04")))
+		rollbackButton := widget.NewButton(fmt.Sprintf("Roll back to v%s", backup.Version), func() {
+			dialog.ShowConfirm("Roll Back",
+				fmt.Sprintf("Replace the installed TurtleSilicon with v%s? The current version is kept so you can switch back.", backup.Version),
+				func(confirmed bool) {
+					if !confirmed {
+						return
+					}
+					rollbackDialog.Hide()
+
+					// Moving the app can mean copying it when the backup is on another volume
+					progressBar := widget.NewProgressBarInfinite()
+					progressDialog := dialog.NewCustomWithoutButtons("Rolling Back",
+						container.NewVBox(widget.NewLabel(fmt.Sprintf("Restoring v%s...", backup.Version)), progressBar), myWindow)
+					progressDialog.Resize(fyne.NewSize(350, 0))
+					progressDialog.Show()
+
+					go func() {
+						err := utils.RollbackApp(backup)
+						fyne.Do(func() {
+							progressBar.Stop()
+							progressDialog.Hide()
+							if err != nil {
+								debug.Printf("Rollback failed: %v", err)
+								dialog.ShowError(fmt.Errorf("failed to roll back: %v", err), myWindow)
+								return
+							}
+							dialog.ShowConfirm("Rollback Complete",
+								fmt.Sprintf("v%s has been restored and will be used after a restart. Would you like to close the application now?", backup.Version),
+								func(restart bool) {
+									if restart {
+										utils.RestartApp()
+										fyne.CurrentApp().Quit()
+									}
+								}, myWindow)
+						})
+					}()
+				}, myWindow)
+		})
+		rows.Add(container.NewBorder(nil, nil, nil, rollbackButton, label))
+	}
+
+	rollbackDialog = dialog.NewCustom("Roll Back to a Previous Version", "Close", rows, myWindow)
+	rollbackDialog.Resize(fyne.NewSize(500, 0))
+	rollbackDialog.Show()
+}
+
+// createUpdateChannelSelect creates a select for the app update channel that saves the choice
+// to the preferences
+func createUpdateChannelSelect() *widget.Select {
+	channels := []string{utils.UpdateChannelStable, utils.UpdateChannelBeta}
+	labels := []string{"Stable releases", "Beta releases"}
+
+	channelSelect := widget.NewSelect(labels, nil)
+	channelSelect.SetSelectedIndex(0)
+	if prefs, err := utils.LoadPrefs(); err == nil && prefs.UpdateChannel == utils.UpdateChannelBeta {
+		channelSelect.SetSelectedIndex(1)
+	}
+
+	channelSelect.OnChanged = func(string) {
+		prefs, err := utils.LoadPrefs()
+		if err != nil {
+			debug.Printf("Failed to load prefs: %v", err)
+			return
+		}
+		prefs.UpdateChannel = channels[channelSelect.SelectedIndex()]
+		if err := utils.SavePrefs(prefs); err != nil {
+			debug.Printf("Failed to save update channel: %v", err)
+		}
+		debug.Printf("Update channel set to %s", prefs.UpdateChannel)
+	}
+	return channelSelect
+}
+
+// focusLostEntry is an entry that reports when it loses focus
+type focusLostEntry struct {
+	widget.Entry
+	onFocusLost func()
+}
+
+func newFocusLostEntry() *focusLostEntry {
+	entry := &focusLostEntry{}
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+func (e *focusLostEntry) FocusLost() {
+	e.Entry.FocusLost()
+	if e.onFocusLost != nil {
+		e.onFocusLost()
+	}
+}
+
+// createUpdateSourceEntry creates an entry for the repository or mirror app updates come from.
+// The source is saved when the entry is submitted or loses focus, not while typing.
+func createUpdateSourceEntry() *focusLostEntry {
+	sourceEntry := newFocusLostEntry()
+	sourceEntry.SetPlaceHolder(utils.DefaultUpdateSource + " or a mirror URL")
+	if prefs, err := utils.LoadPrefs(); err == nil {
+		sourceEntry.SetText(prefs.UpdateSource)
+	}
+
+	sourceEntry.Validator = func(text string) error {
+		_, err := utils.ReleasesURL(text)
+		return err
+	}
+	save := func() {
+		text := strings.TrimSpace(sourceEntry.Text)
+		if _, err := utils.ReleasesURL(text); err != nil {
+			return
+		}
+		prefs, err := utils.LoadPrefs()
+		if err != nil {
+			debug.Printf("Failed to load prefs: %v", err)
+			return
+		}
+		if prefs.UpdateSource == text {
+			return
+		}
+		prefs.UpdateSource = text
+		if err := utils.SavePrefs(prefs); err != nil {
+			debug.Printf("Failed to save update source: %v", err)
+		}
+		debug.Printf("Update source set to %q", text)
+	}
+	sourceEntry.OnSubmitted = func(string) { save() }
+	sourceEntry.onFocusLost = save
+	return sourceEntry
+}
+
 // formatFileSize formats a file size in bytes to a human-readable string
 func formatFileSize(bytes int64) string {
 	const (
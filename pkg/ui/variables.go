@@ -22,6 +22,9 @@ var (
 	VersionTitleButton *widget.Button
 	VersionTitleText   *widget.RichText
 
+	// Realm selection
+	realmSelect *widget.Select
+
 	// Action buttons
 	launchButton           *widget.Button
 	playButton             *widget.Button
@@ -32,6 +35,7 @@ var (
 	unpatchCrossOverButton *widget.Button
 	startServiceButton     *widget.Button
 	stopServiceButton      *widget.Button
+	addonsButton           *widget.Button
 
 	// Option checkboxes
 	metalHudCheckbox      *widget.Check
@@ -1,10 +1,12 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"turtlesilicon/pkg/addons"
 	"turtlesilicon/pkg/debug"
-	"turtlesilicon/pkg/epochsilicon"
+	"turtlesilicon/pkg/gamefiles"
 	"turtlesilicon/pkg/launcher"
 	"turtlesilicon/pkg/patching"
 	"turtlesilicon/pkg/paths"
@@ -318,6 +320,9 @@ func onVersionChanged(selectedDisplayName string, myWindow fyne.Window) {
 	// Update the logo to match the new version
 	updateLogoForVersion(selectedVersionID)
 
+	// Show the addon update count of the new version
+	updateAddonsButtonBadge()
+
 	debug.Printf("Successfully switched to version: %s", selectedDisplayName)
 }
 
@@ -530,6 +535,9 @@ func RefreshUIForCurrentVersion() {
 	if libSiliconPatchCheckbox != nil {
 		libSiliconPatchCheckbox.SetChecked(currentVersion.Settings.EnableLibSiliconPatch)
 	}
+
+	// Show the saved realms of the current version
+	refreshRealmSelector()
 }
 
 // Version-aware path selection
@@ -588,10 +596,8 @@ func SelectCurrentVersionGamePath(myWindow fyne.Window) {
 		updateVersionPathLabels()
 		UpdateAllStatuses()
 
-		// For EpochSilicon, check required files and offer to download missing ones
-		if currentVersion.ID == "epochsilicon" {
-			checkEpochSiliconFiles(myWindow, selectedPath)
-		}
+		// Check the version's required files and offer to download missing ones
+		checkRequiredFiles(myWindow, currentVersion)
 	}, myWindow)
 
 	folderDialog.Resize(fyne.NewSize(dialogWidth, dialogHeight))
@@ -661,29 +667,10 @@ func PatchCurrentVersion(myWindow fyne.Window) {
 		return
 	}
 
-	// For EpochSilicon, check required files before patching
-	if currentVersion.ID == "epochsilicon" {
-		missingFiles, err := epochsilicon.CheckEpochSiliconFiles(currentVersion.GamePath)
-		if err != nil {
-			dialog.ShowError(err, myWindow)
-			return
-		}
-
-		if len(missingFiles) > 0 {
-			epochsilicon.ShowMissingFilesDialog(myWindow, missingFiles, func() {
-				epochsilicon.DownloadMissingFiles(myWindow, currentVersion.GamePath, missingFiles, func(success bool) {
-					if success {
-						// After successful download, proceed with patching
-						proceedWithPatching(myWindow)
-					}
-				})
-			})
-			return
-		}
-	}
-
-	// Proceed with normal patching
-	proceedWithPatching(myWindow)
+	// Check required files before patching
+	ensureRequiredFiles(myWindow, currentVersion, func() {
+		proceedWithPatching(myWindow)
+	})
 }
 
 // proceedWithPatching performs the actual patching operation
@@ -717,44 +704,101 @@ func LaunchCurrentVersion(myWindow fyne.Window) {
 		dialog.ShowError(fmt.Errorf("no current version selected"), myWindow)
 		return
 	}
-
-	launcher.LaunchVersionGame(
-		myWindow,
-		currentVersion.ID,
-		currentVersion.GamePath,
-		currentVersion.CrossOverPath,
-		currentVersion.ExecutableName,
-		currentVersion.Settings.EnableMetalHud,
-		currentVersion.Settings.EnvironmentVariables,
-		currentVersion.Settings.AutoDeleteWdb,
-	)
+	// Make sure the version's required files are present and current before launching
+	ensureRequiredFiles(myWindow, currentVersion, func() {
+		launchVersion(currentVersion, myWindow)
+	})
 }
 
-// checkEpochSiliconFiles checks for required EpochSilicon files and offers to download missing ones
-func checkEpochSiliconFiles(myWindow fyne.Window, gamePath string) {
-	// Always update realmlist.wtf for EpochSilicon when setting path
+// ensureRequiredFiles checks required files of a version and calls onReady once they're in
+// place. Optional files that were never installed are ignored, and as long as no required
+// file is missing the user may skip the download.
+func ensureRequiredFiles(myWindow fyne.Window, gameVersion *version.GameVersion, onReady func()) {
+	requiredFiles := requiredFilesToCheck(gameVersion)
+	if len(requiredFiles) == 0 {
+		onReady()
+		return
+	}
+
 	go func() {
-		if err := epochsilicon.UpdateRealmlistForEpochSilicon(gamePath); err != nil {
-			debug.Printf("Warning: Failed to update realmlist.wtf: %v", err)
-		}
+		changedFiles, err := gamefiles.Check(context.Background(), gameVersion.GamePath, requiredFiles)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			blockingFiles := gamefiles.Blocking(changedFiles)
+			if len(blockingFiles) == 0 {
+				onReady()
+				return
+			}
+
+			var onSkip func()
+			if gamefiles.CanSkip(blockingFiles) {
+				onSkip = onReady
+			}
+			gamefiles.ShowMissingFilesDialog(myWindow, blockingFiles, func() {
+				gamefiles.DownloadMissingFiles(myWindow, gameVersion.GamePath, blockingFiles, func(success bool) {
+					if success {
+						UpdateAllStatuses()
+						onReady()
+					}
+				})
+			}, onSkip)
+		})
 	}()
+}
 
-	// Check for missing files
-	missingFiles, err := epochsilicon.CheckEpochSiliconFiles(gamePath)
-	if err != nil {
-		dialog.ShowError(err, myWindow)
+// launchVersion starts the game of a version
+func launchVersion(gameVersion *version.GameVersion, myWindow fyne.Window) {
+	// Point the game at the selected realm, in case another one was played last
+	if err := applySelectedRealm(gameVersion); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to switch realm: %v", err), myWindow)
 		return
 	}
 
-	if len(missingFiles) > 0 {
-		epochsilicon.ShowMissingFilesDialog(myWindow, missingFiles, func() {
-			epochsilicon.DownloadMissingFiles(myWindow, gamePath, missingFiles, func(success bool) {
-				if success {
-					dialog.ShowInformation("Download Complete", "All Project Epoch files have been downloaded successfully!", myWindow)
-					// Refresh the UI to reflect any changes
-					UpdateAllStatuses()
-				}
-			})
-		})
+	// Back up addon settings before the game gets a chance to rewrite them
+	go addons.SnapshotSavedVariablesIfDue(gameVersion)
+
+	launcher.LaunchVersionGame(
+		myWindow,
+		gameVersion.ID,
+		gameVersion.GamePath,
+		gameVersion.CrossOverPath,
+		gameVersion.ExecutableName,
+		gameVersion.Settings.EnableMetalHud,
+		gameVersion.Settings.EnvironmentVariables,
+		gameVersion.Settings.AutoDeleteWdb,
+	)
+}
+
+// checkRequiredFiles checks a version's required files and offers to download missing or
+// outdated ones
+func checkRequiredFiles(myWindow fyne.Window, gameVersion *version.GameVersion) {
+	gamePath := gameVersion.GamePath
+	requiredFiles := requiredFilesToCheck(gameVersion)
+	if len(requiredFiles) == 0 {
+		return
 	}
+	go func() {
+		missingFiles, err := gamefiles.Check(context.Background(), gamePath, requiredFiles)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			if len(missingFiles) == 0 {
+				return
+			}
+			gamefiles.ShowMissingFilesDialog(myWindow, missingFiles, func() {
+				gamefiles.DownloadMissingFiles(myWindow, gamePath, missingFiles, func(success bool) {
+					if success {
+						dialog.ShowInformation("Download Complete", "All required game files have been downloaded successfully!", myWindow)
+						// Refresh the UI to reflect any changes
+						UpdateAllStatuses()
+					}
+				})
+			}, nil)
+		})
+	}()
 }
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+)
+
+// maxAppBackups is how many previous app versions are kept for rolling back
+const maxAppBackups = 3
+
+// AppBackup is a previous version of the app that can be rolled back to
+type AppBackup struct {
+	Version string
+	Path    string
+	Created time.Time
+}
+
+func getAppBackupDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "TurtleSilicon", "AppBackups"), nil
+}
+
+// currentAppBundlePath returns the .app bundle the running executable belongs to
+func currentAppBundlePath() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %v", err)
+	}
+
+	// Navigate up to find the .app bundle
+	appPath := execPath
+	for !strings.HasSuffix(appPath, ".app") && appPath != "/" {
+		appPath = filepath.Dir(appPath)
+	}
+	if !strings.HasSuffix(appPath, ".app") {
+		return "", fmt.Errorf("could not find app bundle path")
+	}
+	return appPath, nil
+}
+
+// moveApp moves an app bundle, copying it when the destination is on another volume
+func moveApp(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := CopyDir(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// backupApp moves the app bundle into the backup folder, named after its version
+func backupApp(appPath string) (string, error) {
+	dir, err := getAppBackupDir()
+	if err != nil {
+		return "", err
+	}
+	return backupAppTo(appPath, dir)
+}
+
+func backupAppTo(appPath, dir string) (string, error) {
+	info, err := readBundleInfo(appPath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	backupPath := filepath.Join(dir, fmt.Sprintf("TurtleSilicon-%s.app", info.Version))
+	if err := os.RemoveAll(backupPath); err != nil {
+		return "", err
+	}
+	debug.Printf("Backing up app: %s -> %s", appPath, backupPath)
+	if err := moveApp(appPath, backupPath); err != nil {
+		return "", err
+	}
+
+	// Renaming keeps the old modification time, which is what backups are ordered by
+	now := time.Now()
+	os.Chtimes(backupPath, now, now)
+	return backupPath, nil
+}
+
+// ListAppBackups returns the previous app versions that can be rolled back to, newest first
+func ListAppBackups() ([]AppBackup, error) {
+	dir, err := getAppBackupDir()
+	if err != nil {
+		return nil, err
+	}
+	return listAppBackups(dir)
+}
+
+func listAppBackups(dir string) ([]AppBackup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []AppBackup
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".app") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := readBundleInfo(path)
+		if err != nil {
+			debug.Printf("Skipping unreadable app backup %s: %v", path, err)
+			continue
+		}
+		fileInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, AppBackup{Version: info.Version, Path: path, Created: fileInfo.ModTime()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// pruneAppBackups removes all but the newest keep backups
+func pruneAppBackups(keep int) {
+	dir, err := getAppBackupDir()
+	if err != nil {
+		return
+	}
+	pruneAppBackupsIn(dir, keep)
+}
+
+func pruneAppBackupsIn(dir string, keep int) {
+	backups, err := listAppBackups(dir)
+	if err != nil || len(backups) <= keep {
+		return
+	}
+	for _, backup := range backups[keep:] {
+		debug.Printf("Removing old app backup: %s", backup.Path)
+		if err := os.RemoveAll(backup.Path); err != nil {
+			debug.Printf("Failed to remove app backup %s: %v", backup.Path, err)
+		}
+	}
+}
+
+// RollbackApp replaces the running app with a backup. The current version is backed up
+// first, so the rollback can be undone the same way.
+func RollbackApp(backup AppBackup) error {
+	appPath, err := currentAppBundlePath()
+	if err != nil {
+		return err
+	}
+	dir, err := getAppBackupDir()
+	if err != nil {
+		return err
+	}
+	return rollbackApp(appPath, backup, dir)
+}
+
+func rollbackApp(appPath string, backup AppBackup, dir string) error {
+	info, err := readBundleInfo(backup.Path)
+	if err != nil {
+		return fmt.Errorf("the backup is unreadable: %v", err)
+	}
+	if info.BundleID != AppBundleID {
+		return fmt.Errorf("the backup has bundle identifier %q, expected %q", info.BundleID, AppBundleID)
+	}
+
+	// Move the backup out of the way first, backing up the current app may reuse its name
+	stagingPath := appPath + ".rollback"
+	os.RemoveAll(stagingPath)
+	if err := moveApp(backup.Path, stagingPath); err != nil {
+		return fmt.Errorf("failed to prepare rollback: %v", err)
+	}
+
+	currentBackup, err := backupAppTo(appPath, dir)
+	if err != nil {
+		moveApp(stagingPath, backup.Path)
+		return fmt.Errorf("failed to back up the current app: %v", err)
+	}
+
+	debug.Printf("Rolling back to %s: %s -> %s", info.Version, backup.Path, appPath)
+	if err := moveApp(stagingPath, appPath); err != nil {
+		moveApp(stagingPath, backup.Path)
+		moveApp(currentBackup, appPath)
+		return fmt.Errorf("failed to restore v%s: %v", info.Version, err)
+	}
+
+	pruneAppBackupsIn(dir, maxAppBackups)
+	return nil
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestApp(t *testing.T, appPath, version string) {
+	t.Helper()
+	os.MkdirAll(filepath.Join(appPath, "Contents"), 0755)
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+<key>CFBundleIdentifier</key><string>` + AppBundleID + `</string>
+<key>CFBundleShortVersionString</key><string>` + version + `</string>
+</dict></plist>`
+	if err := os.WriteFile(filepath.Join(appPath, "Contents", "Info.plist"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppBackupAndRollback(t *testing.T) {
+	root := t.TempDir()
+	backupDir := filepath.Join(root, "AppBackups")
+	appPath := filepath.Join(root, "Applications", "TurtleSilicon.app")
+
+	// Install 1.3.0 to 1.6.0 in turn, backing up the previous version each time
+	for i, version := range []string{"1.3.0", "1.4.0", "1.5.0", "1.6.0"} {
+		if i > 0 {
+			backupPath, err := backupAppTo(appPath, backupDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			past := time.Now().Add(time.Duration(i-10) * time.Minute)
+			os.Chtimes(backupPath, past, past)
+		}
+		writeTestApp(t, appPath, version)
+	}
+
+	backups, err := listAppBackups(backupDir)
+	if err != nil || len(backups) != 3 || backups[0].Version != "1.5.0" {
+		t.Fatalf("unexpected backups %+v, %v", backups, err)
+	}
+	pruneAppBackupsIn(backupDir, 2)
+	if backups, _ := listAppBackups(backupDir); len(backups) != 2 || backups[1].Version != "1.4.0" {
+		t.Fatalf("pruning kept %+v", backups)
+	}
+
+	if err := rollbackApp(appPath, backups[1], backupDir); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := readBundleInfo(appPath); err != nil || info.Version != "1.4.0" {
+		t.Fatalf("installed app after rollback: %+v, %v", info, err)
+	}
+
+	// The rolled back version is kept so the rollback can be undone
+	backups, _ = listAppBackups(backupDir)
+	if len(backups) != 2 || backups[0].Version != "1.6.0" || backups[1].Version != "1.5.0" {
+		t.Errorf("backups after rollback: %+v", backups)
+	}
+}
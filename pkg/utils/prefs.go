@@ -8,6 +8,8 @@ import (
 
 type UserPrefs struct {
 	SuppressedUpdateVersion string `json:"suppressed_update_version"`
+	UpdateChannel           string `json:"update_channel"` // UpdateChannelStable or UpdateChannelBeta; empty means stable
+	UpdateSource            string `json:"update_source"`  // GitHub owner/repo or mirror URL; empty means DefaultUpdateSource
 	TurtleWoWPath           string `json:"turtlewow_path"`
 	CrossOverPath           string `json:"crossover_path"`
 	EnvironmentVariables    string `json:"environment_variables"`
@@ -24,6 +26,23 @@ type UserPrefs struct {
 	SetShadowLOD0         bool `json:"set_shadow_lod_0"`
 	EnableLibSiliconPatch bool `json:"enable_lib_silicon_patch"`
 
+	// Addon manager settings
+	AddonConcurrency    int  `json:"addon_concurrency"`
+	AddonTimeoutSeconds int  `json:"addon_timeout_seconds"`
+	PreferSystemGit     bool `json:"prefer_system_git"`
+
+	// Hours between automatic SavedVariables snapshots, 0 disables them
+	SavedVariablesSnapshotHours int `json:"saved_variables_snapshot_hours"`
+	// Folder for WTF backups, e.g. a synced cloud folder; empty uses the config directory
+	WTFBackupDir string `json:"wtf_backup_dir"`
+	// URL or path of the last loaded addon catalog
+	AddonCatalogSource string `json:"addon_catalog_source"`
+	// Background update checks: at app start and every N hours (0 disables), optionally
+	// updating unpinned addons without local changes
+	AddonCheckOnStart       bool `json:"addon_check_on_start"`
+	AddonCheckIntervalHours int  `json:"addon_check_interval_hours"`
+	AutoUpdateAddons        bool `json:"auto_update_addons"`
+
 	// Tracking whether user has manually disabled these settings
 	UserDisabledShadowLOD       bool `json:"user_disabled_shadow_lod"`
 	UserDisabledLibSiliconPatch bool `json:"user_disabled_lib_silicon_patch"`
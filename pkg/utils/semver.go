@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version such as 1.4.0 or v1.5.0-beta.2
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseVersion parses a semantic version. A leading "v" and build metadata are ignored, and a
+// missing minor or patch number counts as 0.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	input := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.Prerelease = s[i+1:]
+		s = s[:i]
+		if v.Prerelease == "" {
+			return Version{}, fmt.Errorf("invalid version %q: empty prerelease", input)
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", input)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", input)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// IsPrerelease reports whether the version has a prerelease suffix
+func (v Version) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// String formats the version without a "v" prefix
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is older than, the same as or newer than other, following
+// the semver precedence rules
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff != 0 {
+			return sign(diff)
+		}
+	}
+
+	// A release is newer than any of its prereleases
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	a := strings.Split(v.Prerelease, ".")
+	b := strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePrereleaseIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePrereleaseIdentifier compares numeric identifiers numerically and others as text,
+// with numeric identifiers sorting first
+func comparePrereleaseIdentifier(a, b string) int {
+	numA, errA := strconv.Atoi(a)
+	numB, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(numA - numB)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsNewerVersion reports whether latest is a newer version than current. Versions that
+// can't be parsed are only compared for equality.
+func IsNewerVersion(latest, current string) bool {
+	latestVersion, err := ParseVersion(latest)
+	if err != nil {
+		return strings.TrimPrefix(latest, "v") != strings.TrimPrefix(current, "v")
+	}
+	currentVersion, err := ParseVersion(current)
+	if err != nil {
+		return strings.TrimPrefix(latest, "v") != strings.TrimPrefix(current, "v")
+	}
+	return latestVersion.Compare(currentVersion) > 0
+}
@@ -0,0 +1,49 @@
+package utils
+
+import "testing"
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.4.1", "1.4.0", true},
+		{"1.4.0", "1.4.0", false},
+		{"v1.4.0", "1.5.0", false},
+		{"1.10.0", "1.9.0", true},
+		{"1.5", "1.4.9", true},
+		{"1.5.0-beta.1", "1.4.0", true},
+		{"1.5.0-beta.1", "1.5.0", false},
+		{"1.5.0", "1.5.0-beta.2", true},
+		{"1.5.0-beta.10", "1.5.0-beta.2", true},
+		{"1.5.0-rc.1", "1.5.0-beta.2", true},
+		{"1.5.0-beta", "1.5.0-beta.1", false},
+		{"1.5.0+build.7", "1.5.0", false},
+		{"nightly", "1.4.0", true},
+	}
+	for _, tt := range tests {
+		if got := IsNewerVersion(tt.latest, tt.current); got != tt.want {
+			t.Errorf("IsNewerVersion(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	releases := []UpdateInfo{
+		{TagName: "v1.3.0"},
+		{TagName: "nightly"},
+		{TagName: "v1.5.0-beta.1", Prerelease: true},
+		{TagName: "v1.4.1"},
+	}
+	sortReleases(releases)
+
+	if got := LatestRelease(releases, UpdateChannelStable); got == nil || got.TagName != "v1.4.1" {
+		t.Errorf("stable channel: got %+v, want v1.4.1", got)
+	}
+	if got := LatestRelease(releases, UpdateChannelBeta); got == nil || got.TagName != "v1.5.0-beta.1" {
+		t.Errorf("beta channel: got %+v, want v1.5.0-beta.1", got)
+	}
+	if releases[len(releases)-1].TagName != "nightly" {
+		t.Errorf("releases without a version should sort last, got %v", releases)
+	}
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/download"
+)
+
+// DefaultUpdateSource is the GitHub repository app updates come from unless the preferences
+// name another one
+const DefaultUpdateSource = "tairasu/TurtleSilicon"
+
+const releaseRequestTimeout = 30 * time.Second
+
+// RateLimitError is returned when the GitHub API refuses requests until Reset
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub API rate limit exceeded, try again after %s", e.Reset.Local().Format("15:04"))
+}
+
+// releaseCache keeps the last releases response so unchanged releases can be revalidated
+// with If-None-Match, which doesn't count against the rate limit
+type releaseCache struct {
+	URL            string          `json:"url"`
+	ETag           string          `json:"etag"`
+	Body           json.RawMessage `json:"body"`
+	RateLimitReset time.Time       `json:"rate_limit_reset"`
+}
+
+// ReleasesURL returns the releases API endpoint of an update source. The source is a GitHub
+// "owner/repo", a github.com repository URL or the URL of a mirror serving GitHub's releases
+// JSON. An empty source means DefaultUpdateSource.
+func ReleasesURL(source string) (string, error) {
+	source = strings.TrimSuffix(strings.TrimSpace(source), "/")
+	if source == "" {
+		source = DefaultUpdateSource
+	}
+
+	parsedURL, err := url.Parse(source)
+	if err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") {
+		if strings.EqualFold(parsedURL.Host, "github.com") {
+			source = strings.TrimSuffix(strings.Trim(parsedURL.Path, "/"), ".git")
+		} else {
+			return source, nil
+		}
+	}
+
+	parts := strings.Split(source, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid update source %q: expected owner/repo or a URL", source)
+	}
+	return fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", parts[0], parts[1]), nil
+}
+
+// withPerPage sets the per_page query parameter, keeping any query a mirror URL already has
+func withPerPage(endpoint string, perPage int) (string, error) {
+	parsedURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid update source %s: %v", endpoint, err)
+	}
+	query := parsedURL.Query()
+	query.Set("per_page", strconv.Itoa(perPage))
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String(), nil
+}
+
+func getReleaseCachePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "TurtleSilicon", "release_cache.json"), nil
+}
+
+func loadReleaseCache() *releaseCache {
+	cache := &releaseCache{}
+	path, err := getReleaseCachePath()
+	if err != nil {
+		return cache
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, cache)
+	}
+	return cache
+}
+
+func (c *releaseCache) save() {
+	path, err := getReleaseCachePath()
+	if err != nil {
+		return
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		debug.Printf("Failed to save release cache: %v", err)
+	}
+}
+
+// fetchReleasesJSON returns the releases JSON of an update source. While rate limited, the
+// last response is returned instead if there is one.
+func fetchReleasesJSON(source string) ([]byte, error) {
+	endpoint, err := ReleasesURL(source)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint, err = withPerPage(endpoint, 100); err != nil {
+		return nil, err
+	}
+
+	cache := loadReleaseCache()
+	if cache.URL != endpoint {
+		cache = &releaseCache{URL: endpoint}
+	}
+
+	if time.Now().Before(cache.RateLimitReset) {
+		if len(cache.Body) > 0 {
+			debug.Printf("Rate limited until %v, using cached releases", cache.RateLimitReset)
+			return cache.Body, nil
+		}
+		return nil, &RateLimitError{Reset: cache.RateLimitReset}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid update source %s: %v", endpoint, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if cache.ETag != "" && len(cache.Body) > 0 {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+
+	resp, err := download.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		debug.Printf("Releases unchanged since the last check")
+		return cache.Body, nil
+	}
+
+	if reset, limited := rateLimitReset(resp); limited {
+		cache.RateLimitReset = reset
+		cache.save()
+		if len(cache.Body) > 0 {
+			debug.Printf("Rate limited until %v, using cached releases", reset)
+			return cache.Body, nil
+		}
+		return nil, &RateLimitError{Reset: reset}
+	}
+
+	// Check for HTTP errors
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Read the response body first to check content
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	// Check if response looks like HTML (captive portals or proxy error pages)
+	bodyStr := string(body)
+	if strings.Contains(bodyStr, "<!DOCTYPE html>") || strings.Contains(bodyStr, "<html") {
+		return nil, fmt.Errorf("update source returned HTML instead of JSON: %s", bodyStr[:min(200, len(bodyStr))])
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("update source returned invalid JSON: %s", bodyStr[:min(200, len(bodyStr))])
+	}
+
+	cache.ETag = resp.Header.Get("ETag")
+	cache.Body = body
+	cache.RateLimitReset = time.Time{}
+	cache.save()
+	return body, nil
+}
+
+// rateLimitReset reports whether a response is a rate limit rejection and when requests
+// are allowed again
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second), true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0), true
+		}
+		return time.Now().Add(time.Minute), true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Now().Add(time.Minute), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReleasesURL(t *testing.T) {
+	tests := map[string]string{
+		"":                      "https://api.github.com/repos/tairasu/TurtleSilicon/releases",
+		"someone/TurtleSilicon": "https://api.github.com/repos/someone/TurtleSilicon/releases",
+		"https://github.com/someone/TurtleSilicon": "https://api.github.com/repos/someone/TurtleSilicon/releases",
+		"https://mirror.example.com/releases/":     "https://mirror.example.com/releases",
+	}
+	for source, want := range tests {
+		if got, err := ReleasesURL(source); err != nil || got != want {
+			t.Errorf("ReleasesURL(%q) = %q, %v, want %q", source, got, err, want)
+		}
+	}
+	if _, err := ReleasesURL("not a repo"); err == nil {
+		t.Error("expected an error for an invalid source")
+	}
+}
+
+func TestWithPerPage(t *testing.T) {
+	tests := map[string]string{
+		"https://api.github.com/repos/a/b/releases":      "https://api.github.com/repos/a/b/releases?per_page=100",
+		"https://mirror.example.com/releases?token=x":    "https://mirror.example.com/releases?per_page=100&token=x",
+		"https://mirror.example.com/releases?per_page=5": "https://mirror.example.com/releases?per_page=100",
+	}
+	for endpoint, want := range tests {
+		if got, err := withPerPage(endpoint, 100); err != nil || got != want {
+			t.Errorf("withPerPage(%q) = %q, %v, want %q", endpoint, got, err, want)
+		}
+	}
+}
+
+func TestFetchReleasesJSONCachesAndHonorsRateLimit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	requests := 0
+	rateLimited := false
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if rateLimited {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"r1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"r1"`)
+		w.Write([]byte(`[{"tag_name": "v1.5.0"}]`))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		body, err := fetchReleasesJSON(server.URL)
+		if err != nil || !strings.Contains(string(body), "v1.5.0") {
+			t.Fatalf("request %d: got %s, %v", i+1, body, err)
+		}
+	}
+
+	// Rate limited responses fall back to the cached releases until the reset time
+	rateLimited = true
+	for i := 0; i < 2; i++ {
+		if body, err := fetchReleasesJSON(server.URL); err != nil || !strings.Contains(string(body), "v1.5.0") {
+			t.Fatalf("rate limited request %d: got %s, %v", i+1, body, err)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	// Without a cached response the rate limit is reported
+	var rateLimitErr *RateLimitError
+	if _, err := fetchReleasesJSON(server.URL + "/other"); !errors.As(err, &rateLimitErr) {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/download"
+
+	"howett.net/plist"
+)
+
+// AppBundleID is the bundle identifier an update must have to replace the running app
+const AppBundleID = "com.tairasu.turtlesilicon"
+
+// checksumListNames are release assets that list the checksums of several files
+var checksumListNames = []string{"SHA256SUMS", "SHA256SUMS.txt", "checksums.txt"}
+
+// FindChecksumAsset returns the asset holding the SHA-256 checksum of the named asset: either
+// "<name>.sha256" or a checksum list such as SHA256SUMS. It returns nil if there is none.
+func FindChecksumAsset(updateInfo *UpdateInfo, assetName string) *Asset {
+	for _, suffix := range []string{".sha256", ".sha256sum"} {
+		for i := range updateInfo.Assets {
+			if strings.EqualFold(updateInfo.Assets[i].Name, assetName+suffix) {
+				return &updateInfo.Assets[i]
+			}
+		}
+	}
+	for _, name := range checksumListNames {
+		for i := range updateInfo.Assets {
+			if strings.EqualFold(updateInfo.Assets[i].Name, name) {
+				return &updateInfo.Assets[i]
+			}
+		}
+	}
+	return nil
+}
+
+// VerifyUpdateChecksum downloads the published checksum of an asset and compares it with the
+// SHA-256 of the downloaded file
+func VerifyUpdateChecksum(path string, checksumAsset Asset, assetName string) error {
+	resp, err := download.HTTPClient.Get(checksumAsset.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download checksum: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download checksum: server returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to download checksum: %v", err)
+	}
+
+	expected, err := parseChecksum(string(data), assetName)
+	if err != nil {
+		return err
+	}
+	actual, err := fileSHA256(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash update: %v", err)
+	}
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", assetName, expected, actual)
+	}
+	debug.Printf("Verified checksum of %s: %s", assetName, actual)
+	return nil
+}
+
+// parseChecksum finds the checksum of fileName in the output of sha256sum or shasum, in either
+// GNU ("<hash>  name") or BSD ("SHA256 (name) = <hash>") format. A file holding nothing but
+// a checksum is accepted as well.
+func parseChecksum(data, fileName string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(data), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		var hash, name string
+		if rest, ok := strings.CutPrefix(line, "SHA256 ("); ok {
+			if i := strings.LastIndex(rest, ") = "); i >= 0 {
+				name, hash = rest[:i], rest[i+4:]
+			}
+		} else if fields := strings.Fields(line); len(fields) == 2 {
+			hash, name = fields[0], strings.TrimPrefix(fields[1], "*")
+		} else if len(fields) == 1 && len(lines) == 1 {
+			hash, name = fields[0], fileName
+		}
+
+		if filepath.Base(name) == fileName && isSHA256(hash) {
+			return strings.ToLower(hash), nil
+		}
+	}
+	return "", fmt.Errorf("no SHA-256 checksum for %s found in the checksum file", fileName)
+}
+
+func isSHA256(s string) bool {
+	decoded, err := hex.DecodeString(s)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// bundleInfo is the part of an app's Info.plist the updater looks at
+type bundleInfo struct {
+	BundleID string `plist:"CFBundleIdentifier"`
+	Version  string `plist:"CFBundleShortVersionString"`
+}
+
+// readBundleInfo reads the Info.plist of an app bundle
+func readBundleInfo(appPath string) (*bundleInfo, error) {
+	f, err := os.Open(filepath.Join(appPath, "Contents", "Info.plist"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Info.plist: %v", err)
+	}
+	defer f.Close()
+
+	var info bundleInfo
+	if err := plist.NewDecoder(f).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to parse Info.plist: %v", err)
+	}
+	return &info, nil
+}
+
+// verifyAppBundle checks that an app bundle is TurtleSilicon in the expected version
+func verifyAppBundle(appPath, expectedVersion string) error {
+	info, err := readBundleInfo(appPath)
+	if err != nil {
+		return fmt.Errorf("the update is unreadable: %v", err)
+	}
+
+	if info.BundleID != AppBundleID {
+		return fmt.Errorf("the update has bundle identifier %q, expected %q", info.BundleID, AppBundleID)
+	}
+	if !bundleVersionMatches(info.Version, expectedVersion) {
+		return fmt.Errorf("the update contains version %s, expected %s", info.Version, expectedVersion)
+	}
+	return nil
+}
+
+// bundleVersionMatches compares an app's CFBundleShortVersionString with a release version.
+// Bundle versions can't carry a prerelease suffix, so a bundle without one matches any
+// prerelease of the same version.
+func bundleVersionMatches(bundleVersion, releaseVersion string) bool {
+	bundle, err := ParseVersion(bundleVersion)
+	if err != nil {
+		return false
+	}
+	release, err := ParseVersion(releaseVersion)
+	if err != nil {
+		return false
+	}
+	if bundle.Prerelease == "" {
+		release.Prerelease = ""
+	}
+	return bundle.Compare(release) == 0
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestParseChecksum(t *testing.T) {
+	tests := []struct {
+		name, data string
+		wantErr    bool
+	}{
+		{"bare", testHash + "\n", false},
+		{"gnu", testHash + "  TurtleSilicon.dmg\n", false},
+		{"binary mode", testHash + " *TurtleSilicon.dmg", false},
+		{"bsd", "SHA256 (TurtleSilicon.dmg) = " + testHash, false},
+		{"list", "0000000000000000000000000000000000000000000000000000000000000000  other.zip\n" + testHash + "  dist/TurtleSilicon.dmg\n", false},
+		{"other file", testHash + "  other.dmg", true},
+		{"not a sha256", "abc123  TurtleSilicon.dmg", true},
+	}
+	for _, tt := range tests {
+		got, err := parseChecksum(tt.data, "TurtleSilicon.dmg")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != testHash {
+			t.Errorf("%s: got %q, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestVerifyAppBundle(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "TurtleSilicon.app")
+	os.MkdirAll(filepath.Join(appPath, "Contents"), 0755)
+	writePlist := func(bundleID, version string) {
+		content := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+<key>CFBundleIdentifier</key><string>` + bundleID + `</string>
+<key>CFBundleShortVersionString</key><string>` + version + `</string>
+</dict></plist>`
+		os.WriteFile(filepath.Join(appPath, "Contents", "Info.plist"), []byte(content), 0644)
+	}
+
+	writePlist(AppBundleID, "1.5.0")
+	if err := verifyAppBundle(appPath, "1.5.0"); err != nil {
+		t.Errorf("matching bundle rejected: %v", err)
+	}
+	if err := verifyAppBundle(appPath, "1.5.0-beta.1"); err != nil {
+		t.Errorf("bundle of a prerelease rejected: %v", err)
+	}
+	if err := verifyAppBundle(appPath, "1.5.1"); err == nil {
+		t.Error("bundle with the wrong version accepted")
+	}
+
+	writePlist("com.example.other", "1.5.0")
+	if err := verifyAppBundle(appPath, "1.5.0"); err == nil {
+		t.Error("bundle with the wrong identifier accepted")
+	}
+}
@@ -1,17 +1,21 @@
 package utils
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/download"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
@@ -129,30 +133,38 @@ func QuotePathForShell(path string) string {
 	return fmt.Sprintf(`"%s"`, path)
 }
 
+// Update channels for the app updater: stable only offers full releases, beta also offers prereleases
+const (
+	UpdateChannelStable = "stable"
+	UpdateChannelBeta   = "beta"
+)
+
 func CheckForUpdate(currentVersion string) (latestVersion, releaseNotes string, updateAvailable bool, err error) {
-	resp, err := http.Get("https://api.github.com/repos/tairasu/TurtleSilicon/releases/latest")
+	updateInfo, updateAvailable, err := CheckForUpdateWithAssets(currentVersion)
 	if err != nil {
 		return "", "", false, err
 	}
-	defer resp.Body.Close()
-
-	var data struct {
-		TagName string `json:"tag_name"`
-		Body    string `json:"body"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", "", false, err
-	}
-
-	latest := strings.TrimPrefix(data.TagName, "v")
-	return latest, data.Body, latest != currentVersion, nil
+	return strings.TrimPrefix(updateInfo.TagName, "v"), updateInfo.Body, updateAvailable, nil
 }
 
-// UpdateInfo contains information about the latest release
+// UpdateInfo contains information about a release
 type UpdateInfo struct {
-	TagName string  `json:"tag_name"`
-	Body    string  `json:"body"`
-	Assets  []Asset `json:"assets"`
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	Prerelease  bool      `json:"prerelease"`
+	Draft       bool      `json:"draft"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []Asset   `json:"assets"`
+}
+
+// IsPrerelease reports whether the release is marked as a prerelease or has a prerelease tag
+func (u *UpdateInfo) IsPrerelease() bool {
+	if u.Prerelease {
+		return true
+	}
+	v, err := ParseVersion(u.TagName)
+	return err == nil && v.IsPrerelease()
 }
 
 type Asset struct {
@@ -161,106 +173,104 @@ type Asset struct {
 	Size               int64  `json:"size"`
 }
 
-// CheckForUpdateWithAssets returns update information including download assets
-func CheckForUpdateWithAssets(currentVersion string) (*UpdateInfo, bool, error) {
-	resp, err := http.Get("https://api.github.com/repos/tairasu/TurtleSilicon/releases/latest")
-	if err != nil {
-		return nil, false, err
+// ListReleases returns all published releases of the update source set in the preferences,
+// newest version first. Releases whose tag isn't a version are listed last.
+func ListReleases() ([]UpdateInfo, error) {
+	source := ""
+	if prefs, err := LoadPrefs(); err == nil {
+		source = prefs.UpdateSource
 	}
-	defer resp.Body.Close()
-
-	// Check for HTTP errors
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, false, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Read the response body first to check content
-	body, err := io.ReadAll(resp.Body)
+	data, err := fetchReleasesJSON(source)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
 
-	// Check if response looks like HTML (rate limiting or other errors)
-	bodyStr := string(body)
-	if strings.Contains(bodyStr, "<!DOCTYPE html>") || strings.Contains(bodyStr, "<html") {
-		return nil, false, fmt.Errorf("GitHub API returned HTML instead of JSON (possible rate limiting): %s", bodyStr[:min(200, len(bodyStr))])
+	var releases []UpdateInfo
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse releases: %v", err)
 	}
 
-	var updateInfo UpdateInfo
-	if err := json.Unmarshal(body, &updateInfo); err != nil {
-		return nil, false, fmt.Errorf("failed to parse JSON response: %v. Response: %s", err, bodyStr[:min(200, len(bodyStr))])
+	published := releases[:0]
+	for _, release := range releases {
+		if !release.Draft {
+			published = append(published, release)
+		}
 	}
+	sortReleases(published)
+	return published, nil
+}
 
-	latest := strings.TrimPrefix(updateInfo.TagName, "v")
-	updateAvailable := latest != currentVersion
+// sortReleases orders releases by version, newest first
+func sortReleases(releases []UpdateInfo) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		a, errA := ParseVersion(releases[i].TagName)
+		b, errB := ParseVersion(releases[j].TagName)
+		if errA != nil || errB != nil {
+			return errA == nil && errB != nil
+		}
+		return a.Compare(b) > 0
+	})
+}
 
-	return &updateInfo, updateAvailable, nil
+// LatestRelease returns the newest release available on a channel, or nil if there is none.
+// The releases must be sorted as returned by ListReleases.
+func LatestRelease(releases []UpdateInfo, channel string) *UpdateInfo {
+	for i := range releases {
+		if channel != UpdateChannelBeta && releases[i].IsPrerelease() {
+			continue
+		}
+		if _, err := ParseVersion(releases[i].TagName); err != nil {
+			continue
+		}
+		return &releases[i]
+	}
+	return nil
 }
 
-// DownloadUpdate downloads the latest release and returns the path to the downloaded file
-func DownloadUpdate(downloadURL string, progressCallback func(downloaded, total int64)) (string, error) {
-	// Create temporary file
-	tempFile, err := os.CreateTemp("", "TurtleSilicon-update-*.dmg")
-	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %v", err)
+// CheckForUpdateWithAssets returns the newest release on the update channel set in the
+// preferences, including download assets, and whether it is newer than currentVersion
+func CheckForUpdateWithAssets(currentVersion string) (*UpdateInfo, bool, error) {
+	channel := UpdateChannelStable
+	if prefs, err := LoadPrefs(); err == nil && prefs.UpdateChannel != "" {
+		channel = prefs.UpdateChannel
 	}
-	defer tempFile.Close()
+	return CheckForUpdateOnChannel(currentVersion, channel)
+}
 
-	// Download the file
-	resp, err := http.Get(downloadURL)
+// CheckForUpdateOnChannel returns the newest release on a channel and whether it is newer
+// than currentVersion
+func CheckForUpdateOnChannel(currentVersion, channel string) (*UpdateInfo, bool, error) {
+	releases, err := ListReleases()
 	if err != nil {
-		os.Remove(tempFile.Name())
-		return "", fmt.Errorf("failed to download update: %v", err)
+		return nil, false, err
 	}
-	defer resp.Body.Close()
-
-	totalSize := resp.ContentLength
-	var downloaded int64
 
-	// Copy with progress tracking
-	buffer := make([]byte, 32*1024) // 32KB buffer
-	for {
-		n, err := resp.Body.Read(buffer)
-		if n > 0 {
-			_, writeErr := tempFile.Write(buffer[:n])
-			if writeErr != nil {
-				os.Remove(tempFile.Name())
-				return "", fmt.Errorf("failed to write update file: %v", writeErr)
-			}
-			downloaded += int64(n)
-			if progressCallback != nil {
-				progressCallback(downloaded, totalSize)
-			}
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			os.Remove(tempFile.Name())
-			return "", fmt.Errorf("failed to read update data: %v", err)
-		}
+	latest := LatestRelease(releases, channel)
+	if latest == nil {
+		return nil, false, fmt.Errorf("no releases found on the %s channel", channel)
 	}
-
-	return tempFile.Name(), nil
+	return latest, IsNewerVersion(latest.TagName, currentVersion), nil
 }
 
-// InstallUpdate installs the downloaded update by mounting the DMG and replacing the current app
-func InstallUpdate(dmgPath string) error {
-	// Get current app path
-	execPath, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to get executable path: %v", err)
-	}
+// DownloadUpdate downloads a release asset and returns the path to the downloaded file. An
+// interrupted download of the same asset is resumed.
+func DownloadUpdate(ctx context.Context, downloadURL string, progressCallback func(downloaded, total int64)) (string, error) {
+	// Name the file after the URL so a later attempt finds the partial download
+	urlHash := sha256.Sum256([]byte(downloadURL))
+	downloadPath := filepath.Join(os.TempDir(), fmt.Sprintf("TurtleSilicon-update-%x.dmg", urlHash[:6]))
 
-	// Navigate up to find the .app bundle
-	currentAppPath := execPath
-	for !strings.HasSuffix(currentAppPath, ".app") && currentAppPath != "/" {
-		currentAppPath = filepath.Dir(currentAppPath)
+	if err := download.File(ctx, downloadURL, downloadPath, download.Options{Progress: progressCallback}); err != nil {
+		return "", fmt.Errorf("failed to download update: %v", err)
 	}
+	return downloadPath, nil
+}
 
-	if !strings.HasSuffix(currentAppPath, ".app") {
-		return fmt.Errorf("could not find app bundle path")
+// InstallUpdate installs the downloaded update by mounting the DMG and replacing the current app.
+// It refuses to install an app that isn't TurtleSilicon in expectedVersion.
+func InstallUpdate(dmgPath, expectedVersion string) error {
+	currentAppPath, err := currentAppBundlePath()
+	if err != nil {
+		return err
 	}
 
 	// Mount the DMG and parse the mount point from plist output
@@ -376,32 +386,23 @@ func InstallUpdate(dmgPath string) error {
 
 	debug.Printf("Found app to install: %s", newAppPath)
 
-	// Create backup of current app
-	backupPath := currentAppPath + ".backup"
-	debug.Printf("Creating backup: %s -> %s", currentAppPath, backupPath)
-
-	// Remove old backup if it exists
-	if PathExists(backupPath) {
-		os.RemoveAll(backupPath)
+	if err := verifyAppBundle(newAppPath, expectedVersion); err != nil {
+		return fmt.Errorf("refusing to install update: %v", err)
 	}
 
-	if err := CopyDir(currentAppPath, backupPath); err != nil {
+	// Move the current app to the backups so it can be rolled back to
+	backupPath, err := backupApp(currentAppPath)
+	if err != nil {
 		return fmt.Errorf("failed to create backup: %v", err)
 	}
 
-	// Remove current app
-	debug.Printf("Removing current app: %s", currentAppPath)
-	if err := os.RemoveAll(currentAppPath); err != nil {
-		return fmt.Errorf("failed to remove current app: %v", err)
-	}
-
 	// Copy new app
 	debug.Printf("Installing new app: %s -> %s", newAppPath, currentAppPath)
 	if err := CopyDir(newAppPath, currentAppPath); err != nil {
 		// Try to restore backup on failure
 		debug.Printf("Installation failed, restoring backup")
 		os.RemoveAll(currentAppPath)
-		CopyDir(backupPath, currentAppPath)
+		moveApp(backupPath, currentAppPath)
 		return fmt.Errorf("failed to install new app: %v", err)
 	}
 
@@ -417,8 +418,7 @@ func InstallUpdate(dmgPath string) error {
 		debug.Printf("Warning: executable not found at expected path: %s", executablePath)
 	}
 
-	// Remove backup on success
-	os.RemoveAll(backupPath)
+	pruneAppBackups(maxAppBackups)
 
 	debug.Printf("Update installed successfully")
 	return nil
@@ -18,9 +18,30 @@ type GameVersion struct {
 	SupportsDLLLoading    bool            `json:"supports_dll_loading"`
 	UsesRosettaPatching   bool            `json:"uses_rosetta_patching"`
 	UsesDivxDecoderPatch  bool            `json:"uses_divx_decoder_patch"`
+	RequiredFiles         []RequiredFile  `json:"required_files,omitempty"`
+	Realms                []Realm         `json:"realms,omitempty"`
 	Settings              VersionSettings `json:"settings"`
 }
 
+// LocalePlaceholder in a required file's path is replaced with the client's locale, e.g. enUS
+const LocalePlaceholder = "{locale}"
+
+// RequiredFile is a file a server distributes on top of the base client, such as a custom
+// executable or patch, which is checked before launching
+type RequiredFile struct {
+	RelativePath string `json:"relative_path"`      // Path relative to game directory, may contain LocalePlaceholder
+	DownloadURL  string `json:"download_url"`       // Where the current version is downloaded from
+	SHA256       string `json:"sha256,omitempty"`   // Expected hash; without one the file is compared with the server's copy
+	Optional     bool   `json:"optional,omitempty"` // Offered, but a missing file doesn't hold up launching
+}
+
+// Realm is a saved server the client can be pointed at before launching
+type Realm struct {
+	Name      string `json:"name"`
+	Realmlist string `json:"realmlist"`           // Host written to "set realmlist"
+	Patchlist string `json:"patchlist,omitempty"` // Host written to "set patchlist", if the server uses one
+}
+
 type VersionSettings struct {
 	EnableVanillaTweaks  bool   `json:"enable_vanilla_tweaks"`
 	RemapOptionAsAlt     bool   `json:"remap_option_as_alt"`
@@ -29,6 +50,7 @@ type VersionSettings struct {
 	SaveSudoPassword     bool   `json:"save_sudo_password"`
 	ShowTerminalNormally bool   `json:"show_terminal_normally"`
 	EnvironmentVariables string `json:"environment_variables"`
+	LastRealm            string `json:"last_realm"` // Name of the realm selected for launching, empty leaves the realmlist alone
 
 	// Graphics settings
 	ReduceTerrainDistance bool `json:"reduce_terrain_distance"`
@@ -67,7 +89,16 @@ var DefaultVersions = map[string]*GameVersion{
 		SupportsDLLLoading:    false,
 		UsesRosettaPatching:   false,
 		UsesDivxDecoderPatch:  true,
-		Settings:              VersionSettings{},
+		RequiredFiles: []RequiredFile{
+			{RelativePath: "Project-Epoch.exe", DownloadURL: "http://updater.project-epoch.net/api/v2/latest?file=Project-Epoch.exe"},
+			{RelativePath: "ClientExtensions.dll", DownloadURL: "http://updater.project-epoch.net/api/v2/latest?file=ClientExtensions.dll"},
+			{RelativePath: "Data/patch-A.MPQ", DownloadURL: "http://updater.project-epoch.net/api/v2/latest?file=patch-A.MPQ"},
+			{RelativePath: "Data/patch-B.MPQ", DownloadURL: "http://updater.project-epoch.net/api/v2/latest?file=patch-B.MPQ"},
+			{RelativePath: "Data/patch-Y.MPQ", DownloadURL: "http://updater.project-epoch.net/api/v2/latest?file=patch-Y.MPQ"},
+			{RelativePath: "Data/patch-Z.MPQ", DownloadURL: "http://updater.project-epoch.net/api/v2/latest?file=patch-Z.MPQ"},
+			{RelativePath: "Data/" + LocalePlaceholder + "/realmlist.wtf", DownloadURL: "http://updater.project-epoch.net/api/v2/latest?file=realmlist"},
+		},
+		Settings: VersionSettings{},
 	},
 	"vanillasilicon": {
 		ID:                    "vanillasilicon",
@@ -145,6 +176,9 @@ func LoadVersionManager() (*VersionManager, error) {
 		if _, exists := vm.Versions[id]; !exists {
 			vm.Versions[id] = &GameVersion{}
 			*vm.Versions[id] = *defaultVersion
+		} else {
+			// The files a built-in server needs come with the app, so saved lists are replaced
+			vm.Versions[id].RequiredFiles = defaultVersion.RequiredFiles
 		}
 	}
 
@@ -198,6 +232,16 @@ func (vm *VersionManager) GetVersion(versionID string) (*GameVersion, error) {
 	return version, nil
 }
 
+// SelectedRealm returns the realm the game is launched with, or nil to leave the realmlist alone
+func (v *GameVersion) SelectedRealm() *Realm {
+	for i := range v.Realms {
+		if v.Realms[i].Name == v.Settings.LastRealm {
+			return &v.Realms[i]
+		}
+	}
+	return nil
+}
+
 func (vm *VersionManager) UpdateVersion(version *GameVersion) error {
 	vm.Versions[version.ID] = version
 	return vm.SaveVersionManager()